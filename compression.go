@@ -0,0 +1,50 @@
+package logpher
+
+import (
+	"compress/gzip"
+	"io"
+	"strings"
+)
+
+// zstdExt defines the extension of zstd compressed files. Compressing with zstd needs the zstd subpackage, but the
+// files are always recognized as rotated files so they're still pruned after switching formats
+const zstdExt = ".zst"
+
+// compressedExts defines the extensions of compressed rotated files that are always recognized
+var compressedExts = []string{gzipExt, zstdExt}
+
+// Compressor defines a compression format for rotated files
+type Compressor interface {
+	Extension() string                             // The extension added to compressed files, including the dot
+	NewWriter(w io.Writer) (io.WriteCloser, error) // Creates a writer that compresses into the supplied writer
+}
+
+// gzipCompressor defines the built in gzip compression format
+type gzipCompressor struct {
+	level int
+}
+
+// Extension gets the gzip extension
+func (g gzipCompressor) Extension() string {
+	return gzipExt
+}
+
+// NewWriter creates a gzip writer at the compressor's level
+func (g gzipCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriterLevel(w, g.level)
+}
+
+// compressedExt gets the compression extension of a rotated file path, checking the compressor's extension as well as
+// the known ones. It returns an empty string for uncompressed files
+func compressedExt(path string, compressor Compressor) string {
+	if compressor != nil && strings.HasSuffix(path, compressor.Extension()) {
+		return compressor.Extension()
+	}
+
+	for _, extension := range compressedExts {
+		if strings.HasSuffix(path, extension) {
+			return extension
+		}
+	}
+	return ""
+}
@@ -1,16 +1,105 @@
 package logpher
 
+import (
+	"compress/gzip"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
 const defaultLevelKey = "default"
 
+// noSeparator defines the configured separator that disables line separators
+const noSeparator = "none"
+
+// Config is shorthand for Configuration
+type Config = Configuration
+
 // Configuration defines the configuration structure for logging
 type Configuration struct {
-	Type    string // The main writer type
-	Combine string // A comma separated string indicating which loggers to combine when using a combination writer
-	File    string // The file path for file-based writers
-	Size    int    // The maximum size in bytes for the rolling writer
-	Count   int    // The maximum file count for the rolling writer
-	Levels  map[string]string
-	writer  writer
+	Type           string            // The main writer type
+	Writer         Writer            // A custom writer, used instead of the writer type when supplied
+	Combine        string            // A comma separated string indicating which loggers to combine when using a combination writer
+	File           string            // The file path for file-based writers
+	Format         string            // The line format for the writers, either "standard", "json", "logfmt", or "csv"
+	CSVColumns     []string          // The columns for the CSV format, defaults to timestamp, level, logger, and message
+	CSVHeader      bool              // Whether file-based writers start new files with a CSV header line
+	Formatter      Formatter         // A custom line formatter for the writers, overrides Format when supplied
+	Time           string            // The Go time layout for line timestamps, defaults to RFC3339
+	Precision      string            // The sub-second precision of the default timestamp layout, "seconds", "millis", "micros", or "nanos"
+	UTC            bool              // Whether to render line timestamps in UTC instead of local time
+	Caller         bool              // Whether to add the file:line of the log call to each line as a "caller" field
+	CallerSkip     int               // Additional stack frames to skip when finding the caller, for logging wrappers
+	MaxLine        int               // The maximum formatted line length in bytes, longer lines are truncated, disabled when zero
+	Colour         string            // Whether the console writer colours levels, either "auto", "always", or "never"
+	Stderr         bool              // Whether the console writer writes to stderr instead of stdout
+	SyncWrites     bool              // Whether file-based writers open files with O_SYNC, so lines survive power loss at a large cost to throughput
+	SyncEvery      int               // Sync the rolling writer's file after this many writes, and at each flush, disabled when zero
+	Size           int               // The maximum size in MB for the rolling writer, defaults to 100MB when zero
+	FileSize       string            // The maximum log file size for the rolling writer as a string such as "100MB" or "100MiB", used instead of Size
+	SIUnits        bool              // Whether MB in Size, Total, and FileSize means 1,000,000 bytes instead of 1,048,576, MiB is always 1,048,576
+	Lines          int64             // The maximum number of lines in a file for the rolling writer, disabled when zero
+	Count          int               // The maximum file count for the rolling writer, defaults to 5 when zero, or is unlimited when an age or total is set
+	Age            time.Duration     // The maximum age of rotated files for the rolling writer, disabled when zero
+	Total          int               // The maximum total size in MB of rotated files for the rolling writer, disabled when zero
+	Compress       bool              // Whether to gzip rotated files for the rolling writer
+	GzipLevel      int               // The gzip level for compressing rotated files, e.g. gzip.BestSpeed, defaults to gzip.DefaultCompression when zero
+	Compressor     Compressor        // The compression format for rotated files, e.g. zstd from the zstd subpackage, defaults to gzip
+	CompressOld    bool              // Whether to gzip existing uncompressed rotated files on startup when compressing
+	Numbered       bool              // Whether the rolling writer suffixes rotated files with numbers instead of timestamps
+	LocalArchive   bool              // Whether the rolling writer's rotated file timestamps are in local time instead of UTC
+	Latest         bool              // Whether the rolling writer maintains a <File>.latest link to the newest rotated file
+	Archive        string            // The directory the rolling writer moves rotated files to, relative to the live file, defaults to alongside it
+	Interval       time.Duration     // The time based rotation interval for the rolling writer, disabled when zero
+	Flush          time.Duration     // How often to flush buffered lines for the rolling writer, buffering is disabled when zero
+	Watch          time.Duration     // How often the rolling writer checks its file still exists, reopening it when it was deleted or replaced, disabled when zero
+	Backoff        time.Duration     // How long the rolling writer stops writing after the disk fills up, disabled when zero
+	FreeSpace      bool              // Whether the rolling writer deletes old files as soon as the disk fills up
+	Retries        int               // How many times the rolling writer retries a failed write, disabled when zero
+	RetryBackoff   time.Duration     // How long the rolling writer waits before the first retry, doubling for each retry after it
+	KeepFailed     bool              // Whether the rolling writer keeps lines that failed to write and writes them ahead of the next line
+	Marker         bool              // Whether the rolling writer starts each rotated file with a marker line naming the previous one
+	Separator      string            // The separator the rolling writer writes after each line, defaults to "\n", or "none" for no separator
+	OnRotate       func(string)      // Called on a separate goroutine with the path of each file the rolling writer rotates
+	ErrorHandler   func(error)       // Receives write, flush, and rotation failures from the rolling writer, and TCP and webhook failures, written to the diagnostics output when nil
+	SyslogNetwork  string            // The network for the syslog writer, either "udp" or "tcp", or empty for the local socket
+	SyslogAddress  string            // The address of the syslog daemon, or a socket path when the network is empty
+	SyslogProtocol string            // The syslog message format, either "rfc3164" or "rfc5424"
+	SyslogTag      string            // The syslog tag, also used as the journald identifier, defaults to the program name
+	TCPAddress     string            // The host:port of the collector for the TCP writer
+	TCPTimeout     time.Duration     // The dial and write timeout for the TCP writer, defaults to 5 seconds
+	TCPTLS         *tls.Config       // The TLS configuration for the TCP writer, which connects without TLS when nil
+	TCPBuffer      int               // The maximum lines the TCP writer buffers while disconnected, defaults to 1000
+	UDPAddress     string            // The host:port of the aggregator for the UDP writer
+	UDPSize        int               // The maximum datagram size for the UDP writer, longer lines are truncated
+	UDPErrors      io.Writer         // Where the UDP writer reports dropped lines, nil to drop them silently
+	HTTPURL        string            // The URL the HTTP writer posts lines to
+	HTTPHeaders    map[string]string // Additional headers for the HTTP writer requests, such as auth tokens
+	HTTPBatch      int               // The number of lines per HTTP writer request, defaults to 100
+	HTTPInterval   time.Duration     // How often the HTTP writer sends partial batches, defaults to 1 second
+	HTTPRetries    int               // How many times the HTTP writer retries failed requests, defaults to 3, disabled when negative
+	Redact         []string          // Field keys whose values are replaced with "***" before formatting
+	RedactValues   *regexp.Regexp    // Field values matching this pattern are replaced with "***" before formatting
+	Ring           int               // Hold this many recent lines below the ring level in memory, written only ahead of a line at or above it, disabled when zero
+	RingLevel      string            // The level that writes out the held lines when a ring is configured, defaults to error
+	Filters        []Filter          // Predicates that all have to accept a line for it to be written
+	Sample         int               // Write the first this many lines with each sample key per interval, sampling is disabled when zero
+	Thereafter     int               // Then write every this many lines with the key, dropping the rest when zero
+	SampleInterval time.Duration     // How often the sample counts are reset, defaults to 1 second
+	SampleBy       string            // What lines are counted by, either "message" (the default) or "logger"
+	Dedupe         time.Duration     // Collapse identical consecutive lines, summarizing repeats after at most this long, disabled when zero
+	Queue          int               // The async queue size, lines are written synchronously when zero
+	Overflow       string            // What to do when the async queue is full, either "block", "drop-oldest", or "drop-newest"
+	Grace          time.Duration     // How long closing waits for the async queue to drain before abandoning it, waits indefinitely when zero
+	ExitCode       int               // The exit code used after logging at the fatal level, defaults to 1
+	Metrics        Metrics           // Hooks for observing writes, rotations, failures, and dropped lines
+	Levels         map[string]string // The levels of loggers by name, also applying to named children, e.g. "app" covers "app.db"
+	Thresholds     map[string]string // The minimum levels for individual writer types, applied after the logger levels
+	writer         Writer
 }
 
 // NewConfiguration creates a new configuration object
@@ -20,6 +109,237 @@ func NewConfiguration() *Configuration {
 	}
 }
 
+// precisionLayouts defines the RFC3339 timestamp layouts for each precision, with fixed width fractions so lines line
+// up and sort lexically
+var precisionLayouts = map[string]string{
+	"seconds": time.RFC3339,
+	"millis":  "2006-01-02T15:04:05.000Z07:00",
+	"micros":  "2006-01-02T15:04:05.000000Z07:00",
+	"nanos":   "2006-01-02T15:04:05.000000000Z07:00",
+}
+
+// gzipLevel gets the configured gzip level, defaulting to the default compression
+func (c *Configuration) gzipLevel() int {
+	if c.GzipLevel == 0 {
+		return gzip.DefaultCompression
+	}
+	return c.GzipLevel
+}
+
+// formatTime formats a log line timestamp using the configured layout and location. Without a layout, RFC3339 is used
+// at the configured precision, which defaults to seconds
+func (c *Configuration) formatTime(t time.Time) string {
+	return string(c.appendTime(nil, t))
+}
+
+// appendTime appends a formatted timestamp to a byte slice, so text lines can be built without allocating it
+func (c *Configuration) appendTime(dst []byte, t time.Time) []byte {
+	if c.UTC {
+		t = t.UTC()
+	}
+
+	if c.Time == "" {
+		layout, ok := precisionLayouts[strings.ToLower(c.Precision)]
+		if !ok {
+			layout = time.RFC3339
+		}
+		return t.AppendFormat(dst, layout)
+	}
+	return t.AppendFormat(dst, c.Time)
+}
+
+// separator gets the line separator for the rolling writer
+func (c *Configuration) separator() string {
+	switch c.Separator {
+	case "":
+		return defaultSeparator
+	case noSeparator:
+		return ""
+	default:
+		return c.Separator
+	}
+}
+
+// fileSize gets the maximum rolling file size in bytes, preferring the human readable size when it's supplied and
+// defaulting to the rolling writer's default size
+func (c *Configuration) fileSize() (int64, error) {
+	if c.FileSize != "" {
+		return parseSize(c.FileSize, c.SIUnits)
+	}
+	if c.Size == 0 {
+		return defaultRollingSize, nil
+	}
+	return int64(c.Size) * c.megabyte(), nil
+}
+
+// fileCount gets the maximum rolled file count, defaulting to the rolling writer's default count unless age or total
+// size based retention is set, in which case zero leaves the count unlimited
+func (c *Configuration) fileCount() int {
+	if c.Count == 0 && c.Age <= 0 && c.Total <= 0 {
+		return defaultRollingCount
+	}
+	return c.Count
+}
+
+// megabyte gets the number of bytes in a configured megabyte, which is binary unless SI units are configured
+func (c *Configuration) megabyte() int64 {
+	if c.SIUnits {
+		return megabyte
+	}
+	return mebibyte
+}
+
+// Validate checks the configuration for unknown settings, missing settings, and settings that conflict, returning an
+// error describing every problem it finds
+func (c *Configuration) Validate() error {
+	var problems []error
+
+	// The writer type and its required settings, which don't apply to custom writers
+	if c.Writer == nil {
+		problems = append(problems, c.validateType(c.Type, false)...)
+	}
+
+	// Line formatting
+	problems = append(problems, oneOf("format", c.Format, standardFormat, jsonFormat, logfmtFormat, csvFormat))
+	if c.Time != "" && c.Precision != "" {
+		problems = append(problems, errors.New("precision only applies to the default timestamp layout, not a custom time layout"))
+	}
+	if _, ok := precisionLayouts[strings.ToLower(c.Precision)]; c.Precision != "" && !ok {
+		problems = append(problems, fmt.Errorf("unknown precision: %q", c.Precision))
+	}
+	if (len(c.CSVColumns) > 0 || c.CSVHeader) && strings.ToLower(c.Format) != csvFormat {
+		problems = append(problems, errors.New("CSV columns and headers require the csv format"))
+	}
+	problems = append(problems, oneOf("colour", c.Colour, colourAuto, colourAlways, colourNever))
+
+	// Rolling file limits
+	if c.FileSize != "" {
+		size, err := parseSize(c.FileSize, c.SIUnits)
+		if err == nil && size <= 0 {
+			err = fmt.Errorf("file size must be positive: %q", c.FileSize)
+		}
+		problems = append(problems, err)
+	}
+	problems = append(problems, notNegative("size", int64(c.Size)), notNegative("lines", c.Lines))
+	problems = append(problems, notNegative("count", int64(c.Count)), notNegative("total", int64(c.Total)))
+	problems = append(problems, notNegative("retries", int64(c.Retries)))
+	if c.GzipLevel != 0 && (c.GzipLevel < gzip.HuffmanOnly || c.GzipLevel > gzip.BestCompression) {
+		problems = append(problems, fmt.Errorf("invalid gzip compression level: %d", c.GzipLevel))
+	}
+	if c.GzipLevel != 0 && c.Compressor != nil {
+		problems = append(problems, errors.New("the gzip level doesn't apply to a custom compressor"))
+	}
+
+	// Levels
+	for logger, level := range c.Levels {
+		problems = append(problems, validLevel(fmt.Sprintf("level for %s", logger), level))
+	}
+	for writerType, level := range c.Thresholds {
+		problems = append(problems, validLevel(fmt.Sprintf("threshold for %s", writerType), level))
+	}
+	if c.RingLevel != "" {
+		problems = append(problems, validLevel("ring level", c.RingLevel))
+	}
+
+	// Line processing
+	problems = append(problems, oneOf("sample by", c.SampleBy, sampleByMessage, sampleByLogger))
+	problems = append(problems, oneOf("overflow", c.Overflow, overflowBlock, overflowDropOldest, overflowDropNewest))
+	problems = append(problems, notNegative("ring", int64(c.Ring)), notNegative("sample", int64(c.Sample)))
+	problems = append(problems, notNegative("queue", int64(c.Queue)))
+	if c.Overflow != "" && c.Queue == 0 {
+		problems = append(problems, errors.New("the overflow policy requires an async queue"))
+	}
+
+	return errors.Join(problems...)
+}
+
+// validateType checks a writer type and the settings it requires, including the types it combines
+func (c *Configuration) validateType(writerType string, recursive bool) []error {
+	switch normalizeType(writerType) {
+	case "", console, journald:
+		return nil
+
+	case combination:
+		if recursive {
+			return []error{errors.New("a combination writer can only be used at the top level")}
+		}
+		if strings.TrimSpace(c.Combine) == "" {
+			return []error{errors.New("the combination writer requires writers to combine")}
+		}
+
+		var problems []error
+		for _, subType := range strings.Split(c.Combine, combinationDelimiter) {
+			problems = append(problems, c.validateType(subType, true)...)
+		}
+		return problems
+
+	case file, rolling:
+		return required(writerType, "file", c.File)
+
+	case syslog:
+		return []error{
+			oneOf("syslog network", c.SyslogNetwork, "udp", "tcp"),
+			oneOf("syslog protocol", c.SyslogProtocol, rfc3164, rfc5424),
+		}
+
+	case tcp:
+		return required(writerType, "TCP address", c.TCPAddress)
+
+	case udp:
+		return required(writerType, "UDP address", c.UDPAddress)
+
+	case webhook:
+		return required(writerType, "HTTP URL", c.HTTPURL)
+
+	default:
+		return []error{fmt.Errorf("unknown writer type: %q", writerType)}
+	}
+}
+
+// normalizeType normalizes a writer type, so types and combined types match regardless of case and surrounding spaces
+func normalizeType(writerType string) string {
+	return strings.ToLower(strings.TrimSpace(writerType))
+}
+
+// required checks that a setting the writer type needs was supplied
+func required(writerType string, name string, value string) []error {
+	if strings.TrimSpace(value) == "" {
+		return []error{fmt.Errorf("the %s writer requires a %s", normalizeType(writerType), name)}
+	}
+	return nil
+}
+
+// oneOf checks that a setting is empty or one of the allowed values, ignoring case
+func oneOf(name string, value string, allowed ...string) error {
+	if value == "" {
+		return nil
+	}
+
+	for _, option := range allowed {
+		if strings.EqualFold(value, option) {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown %s %q, expected one of %s", name, value, strings.Join(allowed, ", "))
+}
+
+// notNegative checks that a numeric setting isn't negative
+func notNegative(name string, value int64) error {
+	if value < 0 {
+		return fmt.Errorf("%s can't be negative: %d", name, value)
+	}
+	return nil
+}
+
+// validLevel checks that a configured level name is known
+func validLevel(name string, level string) error {
+	_, err := ParseLevel(level)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return nil
+}
+
 // getLevel gets the level for a logger
 func (c *Configuration) getLevel(logger string) string {
 
@@ -28,9 +348,9 @@ func (c *Configuration) getLevel(logger string) string {
 		return infoString
 	}
 
-	// Check if the logger has an associated level
-	level, ok := c.Levels[logger]
-	if !ok {
+	// Check if the logger, or a logger it's named after, has an associated level
+	level, matched := c.levelOverride(logger)
+	if matched < 0 {
 
 		// Fall back to the configured default level
 		defaultLevel, ok := c.Levels[defaultLevelKey]
@@ -44,3 +364,24 @@ func (c *Configuration) getLevel(logger string) string {
 
 	return level
 }
+
+// levelOverride finds the level configured for a logger by name, ignoring case. A level configured for a parent
+// applies to its named children, so "app" covers "app.db", and the longest matching name wins. It also returns the
+// length of the name that matched, which is -1 when nothing did
+func (c *Configuration) levelOverride(logger string) (string, int) {
+	name := strings.ToLower(logger)
+	level := ""
+	matched := -1
+	for key, value := range c.Levels {
+		key = strings.ToLower(key)
+		if key == defaultLevelKey || len(key) <= matched {
+			continue
+		}
+
+		if key == name || strings.HasPrefix(name, key+".") {
+			level = value
+			matched = len(key)
+		}
+	}
+	return level, matched
+}
@@ -0,0 +1,58 @@
+package logpher
+
+import (
+	"context"
+	"sync"
+)
+
+// ContextExtractor defines a function that extracts a field value from a context, returning false when the context
+// doesn't carry one
+type ContextExtractor func(ctx context.Context) (interface{}, bool)
+
+// contextField defines a field that's extracted from contexts
+type contextField struct {
+	key     string
+	extract ContextExtractor
+}
+
+// The registered context fields
+var (
+	contextLock   = &sync.RWMutex{}
+	contextFields []contextField
+)
+
+// RegisterContextKey registers a context key whose value is added as a field with the supplied key by
+// Logger.WithContext, e.g. a request ID stored with context.WithValue
+func RegisterContextKey(field string, key interface{}) {
+	RegisterContextExtractor(field, func(ctx context.Context) (interface{}, bool) {
+		value := ctx.Value(key)
+		return value, value != nil
+	})
+}
+
+// RegisterContextExtractor registers a function whose result is added as a field with the supplied key by
+// Logger.WithContext, for tracing libraries that keep their IDs behind an accessor rather than a plain context key
+func RegisterContextExtractor(field string, extract ContextExtractor) {
+	contextLock.Lock()
+	defer contextLock.Unlock()
+	contextFields = append(contextFields, contextField{key: field, extract: extract})
+}
+
+// fieldsFromContext extracts the registered fields that the supplied context carries
+func fieldsFromContext(ctx context.Context) []Field {
+	if ctx == nil {
+		return nil
+	}
+
+	contextLock.RLock()
+	defer contextLock.RUnlock()
+
+	var fields []Field
+	for _, field := range contextFields {
+		value, ok := field.extract(ctx)
+		if ok {
+			fields = append(fields, Any(field.key, value))
+		}
+	}
+	return fields
+}
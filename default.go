@@ -0,0 +1,86 @@
+package logpher
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// defaultName defines the name of the default logger
+const defaultName = "default"
+
+var (
+	defaultLock   = &sync.Mutex{}
+	defaultLogger = &atomic.Value{}
+)
+
+// Default gets the package level default logger, which writes to the console at the info level unless it's been
+// replaced with SetDefault
+func Default() *Logger {
+	logger, _ := defaultLogger.Load().(*Logger)
+	if logger != nil {
+		return logger
+	}
+
+	// Create the console logger on first use, making sure concurrent callers get the same one
+	defaultLock.Lock()
+	defer defaultLock.Unlock()
+
+	logger, _ = defaultLogger.Load().(*Logger)
+	if logger == nil {
+		logger = New(nil).NewLogger(defaultName)
+		defaultLogger.Store(logger)
+	}
+	return logger
+}
+
+// SetDefault replaces the default logger used by the package level functions. A nil logger restores the console logger
+func SetDefault(logger *Logger) {
+	defaultLock.Lock()
+	defer defaultLock.Unlock()
+	defaultLogger.Store(logger)
+}
+
+// Log logs at the supplied level with the default logger. Fields in the data are written as structured fields, like
+// they are by a logger
+func Log(level *Level, data ...interface{}) {
+	Default().log(1, level, data...)
+}
+
+// Debugf logs a formatted message at the debug level with the default logger
+func Debugf(format string, args ...interface{}) {
+	Default().log(1, Debug, fmt.Sprintf(format, args...))
+}
+
+// Infof logs a formatted message at the info level with the default logger
+func Infof(format string, args ...interface{}) {
+	Default().log(1, Info, fmt.Sprintf(format, args...))
+}
+
+// Warnf logs a formatted message at the warn level with the default logger
+func Warnf(format string, args ...interface{}) {
+	Default().log(1, Warn, fmt.Sprintf(format, args...))
+}
+
+// Errorf logs a formatted message at the error level with the default logger
+func Errorf(format string, args ...interface{}) {
+	Default().log(1, Error, fmt.Sprintf(format, args...))
+}
+
+// Panicf logs a formatted message at the panic level with the default logger, then flushes the writer and panics with
+// the message
+func Panicf(format string, args ...interface{}) {
+	logger := Default()
+	message := fmt.Sprintf(format, args...)
+	logger.log(1, Panic, message)
+	logger.Logpher.Flush()
+	panic(message)
+}
+
+// Fatalf logs a formatted message at the fatal level with the default logger, then flushes the writer and exits the
+// process with the configured exit code
+func Fatalf(format string, args ...interface{}) {
+	logger := Default()
+	logger.log(1, Fatal, fmt.Sprintf(format, args...))
+	logger.exit()
+}
@@ -0,0 +1,150 @@
+package logpher
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+// Field defines a structured key/value pair attached to a log line
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Any creates a field with the supplied key and value
+func Any(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// errorKey defines the field key for errors
+const errorKey = "error"
+
+// redacted defines the value written in place of redacted field values
+const redacted = "***"
+
+// String creates a field with a string value
+func String(key string, value string) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Int creates a field with an integer value, written as a number in JSON
+func Int(key string, value int) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Float64 creates a field with a floating point value, written as a number in JSON
+func Float64(key string, value float64) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Bool creates a field with a boolean value, written as a boolean in JSON
+func Bool(key string, value bool) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Duration creates a field with a duration value, written in its human readable form such as "1.5s"
+func Duration(key string, value time.Duration) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Err creates an "error" field with the error's message
+func Err(err error) Field {
+	return Field{Key: errorKey, Value: err}
+}
+
+// Sensitive wraps a field so its value is always redacted, for one-off secrets that aren't covered by the configured
+// redaction
+func Sensitive(field Field) Field {
+	return Field{Key: field.Key, Value: redacted}
+}
+
+// LazyValue defines a field value that's only computed once a line is known to be written
+type LazyValue func() string
+
+// Lazy creates a field value that calls the supplied function only when the line passes the level check, for values
+// that are expensive to build
+func Lazy(value func() string) LazyValue {
+	return value
+}
+
+// String computes the value
+func (v LazyValue) String() string {
+	return v()
+}
+
+// resolveFields computes any lazy field values, so each is only computed once no matter how many writers format it
+func resolveFields(fields []Field) []Field {
+	var resolved []Field
+	for i, field := range fields {
+		value, ok := field.Value.(LazyValue)
+		if !ok {
+			continue
+		}
+
+		if resolved == nil {
+			resolved = make([]Field, len(fields))
+			copy(resolved, fields)
+		}
+		resolved[i].Value = value()
+	}
+
+	if resolved == nil {
+		return fields
+	}
+	return resolved
+}
+
+// String renders the field as a key=value pair
+func (f Field) String() string {
+	return f.Key + "=" + fmt.Sprint(f.Value)
+}
+
+// mergeFields merges two sets of fields, keeping the order of the first set and letting the second set override
+// fields with the same key
+func mergeFields(first []Field, second []Field) []Field {
+	if len(first) == 0 {
+		return second
+	}
+	if len(second) == 0 {
+		return first
+	}
+
+	merged := make([]Field, len(first), len(first)+len(second))
+	copy(merged, first)
+
+	indexes := make(map[string]int, len(merged))
+	for i, field := range merged {
+		indexes[field.Key] = i
+	}
+
+	for _, field := range second {
+		if i, ok := indexes[field.Key]; ok {
+			merged[i] = field
+			continue
+		}
+		indexes[field.Key] = len(merged)
+		merged = append(merged, field)
+	}
+	return merged
+}
+
+// hasField determines if the fields include one with the supplied key
+func hasField(fields []Field, key string) bool {
+	for _, field := range fields {
+		if field.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// appendFields writes fields to a buffer as space prefixed key=value pairs for text formats
+func appendFields(buffer *bytes.Buffer, fields []Field) {
+	for _, field := range fields {
+		buffer.WriteString(" ")
+		buffer.WriteString(field.Key)
+		buffer.WriteString("=")
+		_, _ = fmt.Fprint(buffer, field.Value)
+	}
+}
@@ -0,0 +1,326 @@
+package logpher
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	standardFormat = "standard"
+	jsonFormat     = "json"
+	logfmtFormat   = "logfmt"
+	csvFormat      = "csv"
+	truncated      = "…[truncated]"
+)
+
+// jsonKeys defines the keys used for the standard JSON line properties, which fields can't override
+var jsonKeys = map[string]bool{"timestamp": true, "level": true, "logger": true, "message": true}
+
+// logfmtKeys defines the keys used for the standard logfmt line properties, which fields can't override
+var logfmtKeys = map[string]bool{"ts": true, "level": true, "logger": true, "msg": true}
+
+// csvColumns defines the default columns for the CSV format
+var csvColumns = []string{"timestamp", "level", "logger", "message"}
+
+// Formatter defines a function that formats a log line and its structured fields for a writer
+type Formatter func(logger *Logger, level *Level, line string, fields []Field) string
+
+// newFormatter gets the formatter with the supplied name, using the coloured standard format when colour is requested.
+// The columns are only used by the CSV format
+func newFormatter(name string, colour bool, columns []string) Formatter {
+	switch strings.ToLower(name) {
+	case jsonFormat:
+		return formatJSON
+
+	case logfmtFormat:
+		return formatLogfmt
+
+	case csvFormat:
+		return csvFormatter(columns)
+
+	case standardFormat:
+		fallthrough
+	default:
+		if colour {
+			return formatColour
+		}
+		return formatStandard
+	}
+}
+
+// redacting wraps a formatter so the values of fields with the supplied keys, or with values matching the supplied
+// pattern, are replaced before the line is formatted. The pattern can be nil
+func redacting(format Formatter, keys []string, pattern *regexp.Regexp) Formatter {
+	redact := redactor(keys, pattern)
+	return func(logger *Logger, level *Level, line string, fields []Field) string {
+		return format(logger, level, line, redact(fields))
+	}
+}
+
+// redactor creates a function that replaces the values of fields with the supplied keys, or with values matching the
+// supplied pattern, for writers that don't use a formatter. The pattern can be nil
+func redactor(keys []string, pattern *regexp.Regexp) func([]Field) []Field {
+	redact := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		redact[key] = true
+	}
+
+	return func(fields []Field) []Field {
+		var replaced []Field
+		for i, field := range fields {
+			if !redact[field.Key] && (pattern == nil || !pattern.MatchString(fmt.Sprint(field.Value))) {
+				continue
+			}
+
+			// Copy the fields before changing them, since they're shared between writers
+			if replaced == nil {
+				replaced = make([]Field, len(fields))
+				copy(replaced, fields)
+			}
+			replaced[i].Value = redacted
+		}
+
+		if replaced == nil {
+			return fields
+		}
+		return replaced
+	}
+}
+
+// truncating wraps a formatter so lines longer than the maximum bytes are shortened and marked. The message is
+// shortened first so structured formats stay valid, and text formats are then cut off if the line is still too long
+func truncating(format Formatter, maxBytes int, text bool) Formatter {
+	return func(logger *Logger, level *Level, line string, fields []Field) string {
+		formatted := format(logger, level, line, fields)
+		if len(formatted) <= maxBytes {
+			return formatted
+		}
+
+		// Find the longest part of the message that fits, since escaping can make the formatted message longer
+		low, high := 0, len(line)
+		for low < high {
+			middle := (low + high + 1) / 2
+			if len(format(logger, level, truncate(line, middle)+truncated, fields)) <= maxBytes {
+				low = middle
+			} else {
+				high = middle - 1
+			}
+		}
+
+		formatted = format(logger, level, truncate(line, low)+truncated, fields)
+		if text && len(formatted) > maxBytes {
+			formatted = truncate(formatted, maxBytes-len(truncated)) + truncated
+		}
+		return formatted
+	}
+}
+
+// formatStandard formats a standard log line, without colouring it. Fields are appended as key=value pairs, and the
+// hostname and process ID are a prefix when the logger includes them
+func formatStandard(logger *Logger, level *Level, line string, fields []Field) string {
+	return formatText(logger, level.display, line, fields)
+}
+
+// formatColour formats a standard log line, colouring the level. Fields are appended as key=value pairs, and the
+// hostname and process ID are a prefix when the logger includes them
+func formatColour(logger *Logger, level *Level, line string, fields []Field) string {
+	return formatText(logger, level.colourize(), line, fields)
+}
+
+// formatText formats a standard log line with the supplied level display, building it in a pooled buffer
+func formatText(logger *Logger, level string, line string, fields []Field) string {
+	buffer := getBuffer()
+	defer putBuffer(buffer)
+
+	appendText(buffer, logger, level, line, fields)
+	return buffer.String()
+}
+
+// appendText writes a standard log line with the supplied level display to a buffer, piece by piece so nothing but
+// the field values goes through fmt
+func appendText(buffer *bytes.Buffer, logger *Logger, level string, line string, fields []Field) {
+	logger.appendProcessPrefix(buffer)
+	buffer.WriteString("[")
+	buffer.Write(logger.appendTimestamp(buffer.AvailableBuffer()))
+	buffer.WriteString("] [")
+	buffer.WriteString(logger.name)
+	buffer.WriteString("] [")
+	buffer.WriteString(level)
+	buffer.WriteString("] ")
+	buffer.WriteString(line)
+	appendFields(buffer, fields)
+}
+
+// formatJSON formats a log line as a single JSON object, with fields as top level keys
+func formatJSON(logger *Logger, level *Level, line string, fields []Field) string {
+	buffer := &bytes.Buffer{}
+	buffer.WriteString("{")
+	appendJSON(buffer, "timestamp", logger.timestamp())
+	appendJSON(buffer, "level", level.display)
+	appendJSON(buffer, "logger", logger.name)
+	appendJSON(buffer, "message", line)
+
+	process := logger.process()
+	for _, field := range process {
+		appendJSON(buffer, field.Key, field.Value)
+	}
+
+	// Prefix fields that would override the standard keys
+	for _, field := range fields {
+		key := field.Key
+		if jsonKeys[key] || hasField(process, key) {
+			key = "fields." + key
+		}
+		appendJSON(buffer, key, field.Value)
+	}
+
+	buffer.WriteString("}")
+	return buffer.String()
+}
+
+// appendJSON appends a JSON key/value pair to an object being built in the buffer. Values that can't be encoded are
+// written as strings instead
+func appendJSON(buffer *bytes.Buffer, key string, value interface{}) {
+	if buffer.Len() > 1 {
+		buffer.WriteString(",")
+	}
+	buffer.WriteString(encodeJSON(key))
+	buffer.WriteString(":")
+
+	// Errors and durations are written as their messages and human readable forms, since they don't encode usefully
+	switch typed := value.(type) {
+	case error:
+		buffer.WriteString(encodeJSON(typed.Error()))
+		return
+	case time.Duration:
+		buffer.WriteString(encodeJSON(typed.String()))
+		return
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		buffer.WriteString(encodeJSON(fmt.Sprint(value)))
+		return
+	}
+	buffer.Write(encoded)
+}
+
+// encodeJSON encodes a string as JSON, without escaping HTML characters
+func encodeJSON(value string) string {
+	buffer := &bytes.Buffer{}
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+
+	// Strings can always be encoded
+	panicOnError(encoder.Encode(value))
+
+	// The encoder terminates the value with a newline
+	return strings.TrimSuffix(buffer.String(), "\n")
+}
+
+// formatLogfmt formats a log line as logfmt key/value pairs, with fields as additional pairs
+func formatLogfmt(logger *Logger, level *Level, line string, fields []Field) string {
+	builder := &strings.Builder{}
+	_, _ = fmt.Fprintf(
+		builder,
+		"ts=%s level=%s logger=%s msg=%s",
+		logfmtValue(logger.timestamp()),
+		logfmtValue(level.display),
+		logfmtValue(logger.name),
+		logfmtValue(line),
+	)
+
+	process := logger.process()
+	for _, field := range process {
+		_, _ = fmt.Fprintf(builder, " %s=%s", field.Key, logfmtValue(fmt.Sprint(field.Value)))
+	}
+
+	// Prefix fields that would repeat the standard keys, so the logger name and the rest stay unambiguous
+	for _, field := range fields {
+		key := field.Key
+		if logfmtKeys[key] || hasField(process, key) {
+			key = "fields." + key
+		}
+		_, _ = fmt.Fprintf(builder, " %s=%s", logfmtValue(key), logfmtValue(fmt.Sprint(field.Value)))
+	}
+	return builder.String()
+}
+
+// csvFormatter creates a formatter that writes lines as CSV records with the supplied columns, defaulting to the
+// timestamp, level, logger, and message. Other columns are filled with the process field or field with the same key,
+// and left empty when there isn't one
+func csvFormatter(columns []string) Formatter {
+	if len(columns) == 0 {
+		columns = csvColumns
+	}
+
+	return func(logger *Logger, level *Level, line string, fields []Field) string {
+		record := make([]string, len(columns))
+		for i, column := range columns {
+			record[i] = csvValue(logger, level, line, fields, column)
+		}
+		return csvRecord(record)
+	}
+}
+
+// csvValue gets the value of a CSV column for a log line
+func csvValue(logger *Logger, level *Level, line string, fields []Field, column string) string {
+	switch column {
+	case "timestamp":
+		return logger.timestamp()
+	case "level":
+		return level.display
+	case "logger":
+		return logger.name
+	case "message":
+		return line
+	}
+
+	for _, field := range append(logger.process(), fields...) {
+		if field.Key == column {
+			return fmt.Sprint(field.Value)
+		}
+	}
+	return ""
+}
+
+// csvHeader gets the CSV header line for the supplied columns, defaulting to the standard columns
+func csvHeader(columns []string) string {
+	if len(columns) == 0 {
+		columns = csvColumns
+	}
+	return csvRecord(columns)
+}
+
+// csvRecord encodes a CSV record, quoting values that contain commas, quotes, or newlines
+func csvRecord(record []string) string {
+	buffer := &bytes.Buffer{}
+	writer := csv.NewWriter(buffer)
+
+	// Writing to a buffer can't fail
+	_ = writer.Write(record)
+	writer.Flush()
+
+	// The writer terminates the record with a newline
+	return strings.TrimSuffix(buffer.String(), "\n")
+}
+
+// logfmtValue quotes and escapes a logfmt value when it's empty or contains spaces, quotes, equals signs, or control
+// characters
+func logfmtValue(value string) string {
+	if value == "" || strings.IndexFunc(value, needsLogfmtQuoting) != -1 {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+// needsLogfmtQuoting determines if a character requires a logfmt value to be quoted
+func needsLogfmtQuoting(r rune) bool {
+	return r <= ' ' || r == '=' || r == '"' || r == '\\' || r == 0x7f
+}
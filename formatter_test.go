@@ -0,0 +1,16 @@
+package logpher
+
+import (
+	"testing"
+)
+
+// BenchmarkFormatStandard measures formatting a standard line with a couple of fields
+func BenchmarkFormatStandard(b *testing.B) {
+	logger := New(nil).NewLogger("bench")
+	fields := []Field{Any("status", 200), Any("path", "/health")}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		formatStandard(logger, Info, "request handled", fields)
+	}
+}
@@ -1,8 +1,12 @@
 package logpher
 
 import (
+	"errors"
+	"fmt"
 	"github.com/fatih/color"
+	"sort"
 	"strings"
+	"sync"
 )
 
 const (
@@ -11,41 +15,105 @@ const (
 	infoString  = "INFO"
 	warnString  = "WARN"
 	errString   = "ERROR"
+	panicString = "PANIC"
+	fatalString = "FATAL"
 	offString   = "OFF"
 )
 
+// The built in levels, spaced apart so custom levels can be registered between them
 var (
-	Trace = &level{0, traceString, color.WhiteString}
-	Debug = &level{1, debugString, color.BlueString}
-	Info  = &level{2, infoString, color.CyanString}
-	Warn  = &level{3, warnString, color.YellowString}
-	Error = &level{4, errString, color.RedString}
-	Off   = &level{5, offString, nil}
+	Trace = &Level{0, traceString, newColourizer(color.FgWhite)}
+	Debug = &Level{10, debugString, newColourizer(color.FgBlue)}
+	Info  = &Level{20, infoString, newColourizer(color.FgCyan)}
+	Warn  = &Level{30, warnString, newColourizer(color.FgYellow)}
+	Error = &Level{40, errString, newColourizer(color.FgRed)}
+	Panic = &Level{45, panicString, newColourizer(color.FgHiRed)}
+	Fatal = &Level{50, fatalString, newColourizer(color.FgMagenta)}
+	Off   = &Level{100, offString, nil}
 )
 
-// level defines a logging level
-type level struct {
+var (
+	levelLock = &sync.RWMutex{}
+	levels    = []*Level{Trace, Debug, Info, Warn, Error, Panic, Fatal, Off} // The known levels, in order of severity
+)
+
+// Level defines a logging level
+type Level struct {
 	value      int
 	display    string
-	colourizer func(format string, a ...interface{}) string
+	colourizer *color.Color
 }
 
-// newLevel constructs a new level from a string level name
-func newLevel(level string) *level {
-	switch strings.ToUpper(level) {
-	case traceString:
-		return Trace
-	case debugString:
-		return Debug
-	case infoString:
-		return Info
-	case warnString:
-		return Warn
-	case errString:
-		return Error
-	case offString:
-		return Off
-	default:
+// newColourizer creates a colourizer that always emits colour codes, leaving the decision to colour to the writer
+func newColourizer(attribute color.Attribute) *color.Color {
+	c := color.New(attribute)
+	c.EnableColor()
+	return c
+}
+
+// colourize wraps the level display name in the level's colour codes
+func (l *Level) colourize() string {
+	if l.colourizer == nil {
+		return l.display
+	}
+	return l.colourizer.Sprint(l.display)
+}
+
+// String gets the display name of the level
+func (l *Level) String() string {
+	return l.display
+}
+
+// RegisterLevel registers a custom level with the supplied name and severity. The built in levels have severities of
+// 0 (trace), 10 (debug), 20 (info), 30 (warn), 40 (error), 45 (panic), and 50 (fatal), and custom severities must be between trace and off
+// (100). Registered levels can be parsed by name and logged with Logger.Log
+func RegisterLevel(name string, severity int) (*Level, error) {
+	display := strings.ToUpper(strings.TrimSpace(name))
+	if display == "" {
+		return nil, errors.New("a level name is required")
+	}
+
+	if severity < Trace.value || severity >= Off.value {
+		return nil, fmt.Errorf("level severity must be between %d and %d: %d", Trace.value, Off.value-1, severity)
+	}
+
+	levelLock.Lock()
+	defer levelLock.Unlock()
+
+	for _, level := range levels {
+		if level.display == display {
+			return nil, fmt.Errorf("level already registered: %s", display)
+		}
+	}
+
+	// Insert the level, keeping the levels ordered by severity
+	level := &Level{severity, display, nil}
+	index := sort.Search(len(levels), func(i int) bool {
+		return levels[i].value > severity
+	})
+	levels = append(levels[:index], append([]*Level{level}, levels[index:]...)...)
+	return level, nil
+}
+
+// ParseLevel parses a level from its case-insensitive name, returning an error for unknown names
+func ParseLevel(name string) (*Level, error) {
+	levelLock.RLock()
+	defer levelLock.RUnlock()
+
+	trimmed := strings.TrimSpace(name)
+	for _, level := range levels {
+		if strings.EqualFold(level.display, trimmed) {
+			return level, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown log level: %q", name)
+}
+
+// newLevel constructs a new level from a string level name, falling back to the info level for unknown names
+func newLevel(name string) *Level {
+	level, err := ParseLevel(name)
+	if err != nil {
 		return Info
 	}
+	return level
 }
@@ -1,15 +1,41 @@
 package logpher
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// exit exits the process, replaceable so fatal logging can be exercised without exiting
+var exit = os.Exit
+
+// Field keys for the location of the log call, the stack trace, and the process
+const (
+	callerKey     = "caller"
+	stacktraceKey = "stacktrace"
+	hostnameKey   = "hostname"
+	pidKey        = "pid"
 )
 
 // logger Defines a logger structure
 type Logger struct {
-	Logpher *Logpher `autumn:"logpher"`
-	name    string
-	level   *level
+	Logpher    *Logpher `autumn:"logpher"`
+	name       string
+	level      *atomic.Value
+	fields     []Field
+	callerSkip int
+	stackLevel *Level
+	limiter    *rateLimiter
+	hostname   string
+	pid        int
+	at         time.Time
 }
 
 // newLogger constructs a logger with the specified name, level, and writer
@@ -21,47 +47,322 @@ func newLogger(name string, logpher *Logpher) *Logger {
 
 // Trace logs at the trace level
 func (l *Logger) Trace(data ...interface{}) {
-	l.log(Trace, data...)
+	l.log(1, Trace, data...)
 }
 
 // Debug logs at the debug level
 func (l *Logger) Debug(data ...interface{}) {
-	l.log(Debug, data...)
+	l.log(1, Debug, data...)
 }
 
 // Info logs at the info level
 func (l *Logger) Info(data ...interface{}) {
-	l.log(Info, data...)
+	l.log(1, Info, data...)
 }
 
 // Warn logs at the warn level
 func (l *Logger) Warn(data ...interface{}) {
-	l.log(Warn, data...)
+	l.log(1, Warn, data...)
 }
 
 // Error logs at the error level
 func (l *Logger) Error(data ...interface{}) {
-	l.log(Error, data...)
+	l.log(1, Error, data...)
+}
+
+// Panic logs at the panic level, then flushes the writer and panics with the message
+func (l *Logger) Panic(data ...interface{}) {
+	l.log(1, Panic, data...)
+	message, _ := splitData(data)
+	l.Logpher.Flush()
+	panic(message)
+}
+
+// Panicf logs a formatted message at the panic level, then flushes the writer and panics with the message
+func (l *Logger) Panicf(format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	l.log(1, Panic, message)
+	l.Logpher.Flush()
+	panic(message)
+}
+
+// Fatal logs at the fatal level, then flushes the writer and exits the process with the configured exit code
+func (l *Logger) Fatal(data ...interface{}) {
+	l.log(1, Fatal, data...)
+	l.exit()
+}
+
+// Fatalf logs a formatted message at the fatal level, then flushes the writer and exits the process with the
+// configured exit code
+func (l *Logger) Fatalf(format string, args ...interface{}) {
+	l.log(1, Fatal, fmt.Sprintf(format, args...))
+	l.exit()
+}
+
+// Log logs at the supplied level, which can be a built in or registered level
+func (l *Logger) Log(level *Level, data ...interface{}) {
+	l.log(1, level, data...)
+}
+
+// With creates a child logger that includes the supplied fields on every line. The child shares the parent's writer
+// and level
+func (l *Logger) With(fields ...Field) *Logger {
+	return &Logger{
+		Logpher:    l.Logpher,
+		name:       l.name,
+		level:      l.level,
+		fields:     mergeFields(l.fields, fields),
+		callerSkip: l.callerSkip,
+		stackLevel: l.stackLevel,
+		limiter:    l.limiter,
+		hostname:   l.hostname,
+		pid:        l.pid,
+	}
+}
+
+// Named creates a child logger named after the parent followed by a dot and the suffix, e.g. APP.DB, so subsystems can
+// be told apart and filtered. The child includes the parent's fields and starts at the parent's level, unless a level
+// is configured for its name or a name between the parent's and its own, and its level is changed separately
+func (l *Logger) Named(suffix string) *Logger {
+	child := l.With()
+	child.name = strings.ToUpper(strings.TrimSpace(suffix))
+	if l.name != "" {
+		child.name = l.name + "." + child.name
+	}
+
+	level := l.GetLevel()
+	override, matched := l.Logpher.Configuration.levelOverride(child.name)
+	if matched > len(l.name) {
+		level = newLevel(override)
+	}
+
+	child.level = &atomic.Value{}
+	child.level.Store(level)
+	return child
+}
+
+// WithContext creates a child logger that includes the fields registered with RegisterContextKey and
+// RegisterContextExtractor that the supplied context carries, such as trace and request IDs
+func (l *Logger) WithContext(ctx context.Context) *Logger {
+	return l.With(fieldsFromContext(ctx)...)
+}
+
+// WithHostname creates a child logger that includes the hostname on every line, falling back to "unknown" when it
+// can't be resolved
+func (l *Logger) WithHostname() *Logger {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "unknown"
+	}
+
+	child := l.With()
+	child.hostname = hostname
+	return child
+}
+
+// WithPID creates a child logger that includes the process ID on every line
+func (l *Logger) WithPID() *Logger {
+	child := l.With()
+	child.pid = os.Getpid()
+	return child
+}
+
+// WithCallerSkip creates a child logger that skips additional stack frames when reporting the caller, so logging
+// wrappers can report their own caller's location
+func (l *Logger) WithCallerSkip(skip int) *Logger {
+	child := l.With()
+	child.callerSkip += skip
+	return child
+}
+
+// WithStacktrace creates a child logger that adds the goroutine's stack trace to lines at or above the supplied level.
+// Pass nil to stop adding stack traces
+func (l *Logger) WithStacktrace(level *Level) *Logger {
+	child := l.With()
+	child.stackLevel = level
+	return child
+}
+
+// WithRateLimit creates a child logger that writes at most the supplied number of lines per interval, with bursts of
+// up to the burst size. Excess lines are dropped, and the number dropped is reported in a warning once an interval
+// when the logger's level allows warnings. The child's own children share its limit
+func (l *Logger) WithRateLimit(lines int, interval time.Duration, burst int) *Logger {
+	child := l.With()
+	child.limiter = newRateLimiter(lines, interval, burst)
+	return child
+}
+
+// Close closes the writers the logger writes to, flushing buffered lines and draining async queues first, and returns
+// any errors joined together. The writers are shared by every logger from the same logpher instance, so this is meant
+// to be deferred once on shutdown
+func (l *Logger) Close() error {
+	return l.Logpher.Close()
+}
+
+// Name gets the name of the logger
+func (l *Logger) Name() string {
+	return l.name
+}
+
+// SetLevel changes the level of the logger, and any child loggers created with With. It's safe to call while other
+// goroutines are logging
+func (l *Logger) SetLevel(level *Level) {
+	l.level.Store(level)
+}
+
+// GetLevel gets the current level of the logger
+func (l *Logger) GetLevel() *Level {
+	return l.level.Load().(*Level)
 }
 
 // LevelEnabled determines if logs at the specified level will be written by this logger
-func (l *Logger) LevelEnabled(level *level) bool {
-	return l.level.value <= level.value
+func (l *Logger) LevelEnabled(level *Level) bool {
+	return l.GetLevel().value <= level.value
+}
+
+// Enabled determines if logs at the specified level will be written by this logger, so callers can skip building
+// expensive log data that would be filtered out
+func (l *Logger) Enabled(level *Level) bool {
+	return l.LevelEnabled(level)
 }
 
-// log logs a message at the specified level
-func (l *Logger) log(level *level, data ...interface{}) {
+// log logs a message at the specified level. Any fields in the data are written as structured fields, while the rest
+// of the data is concatenated into the message. The depth is the number of frames between the log call and this
+// function, used to find the caller
+func (l *Logger) log(depth int, level *Level, data ...interface{}) {
 
 	if !l.LevelEnabled(level) {
 		return
 	}
 
-	message := ""
+	message, fields := splitData(data)
+
+	// Add the location of the log call
+	if l.Logpher.Configuration.Caller {
+		caller, ok := callerField(depth + l.Logpher.Configuration.CallerSkip + l.callerSkip + 1)
+		if ok {
+			fields = append([]Field{caller}, fields...)
+		}
+	}
+
+	l.write(level, message, fields)
+}
+
+// splitData separates the fields in log data from the rest of the data, which is concatenated into the message
+func splitData(data []interface{}) (string, []Field) {
+	var items []string
+	var fields []Field
 	for _, item := range data {
-		message += fmt.Sprint(item, " ")
+		if field, ok := item.(Field); ok {
+			fields = append(fields, field)
+			continue
+		}
+		items = append(items, fmt.Sprint(item))
+	}
+	return strings.Join(items, " "), fields
+}
+
+// exit flushes and syncs the writer, then exits the process. Deferred closes don't run on exit, so this is the last
+// chance to get buffered lines out
+func (l *Logger) exit() {
+	l.Logpher.Flush()
+	_ = l.Logpher.Sync()
+
+	code := l.Logpher.Configuration.ExitCode
+	if code == 0 {
+		code = 1
+	}
+	exit(code)
+}
+
+// callerField creates a field with the file:line location of the function the supplied number of frames above the caller
+func callerField(skip int) (Field, bool) {
+	_, file, line, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return Field{}, false
+	}
+	return Any(callerKey, filepath.Base(file)+":"+strconv.Itoa(line)), true
+}
+
+// stacktraceField creates a field with the stack trace of the current goroutine
+func stacktraceField() Field {
+	buffer := make([]byte, 4096)
+	for {
+		n := runtime.Stack(buffer, false)
+		if n < len(buffer) {
+			return Any(stacktraceKey, strings.TrimSpace(string(buffer[:n])))
+		}
+		buffer = make([]byte, len(buffer)*2)
 	}
+}
+
+// write writes a message and its fields to the configured writer, after the logger's own fields. Lines over the rate
+// limit are dropped, and the stack trace is added last when the level requires one
+func (l *Logger) write(level *Level, message string, fields []Field) {
+	writer := l.Logpher.Configuration.writer
+
+	// Apply the rate limit, reporting any lines it dropped unless the logger's level hides warnings
+	if l.limiter != nil {
+		allowed, dropped := l.limiter.allow()
+		if !allowed {
+			return
+		}
+		if dropped > 0 && l.LevelEnabled(Warn) {
+			writer.Write(l, Warn, fmt.Sprintf("dropped %d log lines due to rate limiting", dropped), resolveFields(l.fields))
+		}
+	}
+
+	if l.stackLevel != nil && level.value >= l.stackLevel.value {
+		fields = append(fields, stacktraceField())
+	}
+	writer.Write(l, level, message, resolveFields(mergeFields(l.fields, fields)))
+}
+
+// process gets the hostname and process ID fields the logger includes on every line
+func (l *Logger) process() []Field {
+	var fields []Field
+	if l.hostname != "" {
+		fields = append(fields, String(hostnameKey, l.hostname))
+	}
+	if l.pid != 0 {
+		fields = append(fields, Int(pidKey, l.pid))
+	}
+	return fields
+}
+
+// appendProcessPrefix writes the hostname and process ID to a buffer as a bracketed prefix for text formats
+func (l *Logger) appendProcessPrefix(buffer *bytes.Buffer) {
+	if l.hostname != "" {
+		buffer.WriteString("[")
+		buffer.WriteString(l.hostname)
+		buffer.WriteString("] ")
+	}
+	if l.pid != 0 {
+		buffer.WriteString("[")
+		buffer.Write(strconv.AppendInt(buffer.AvailableBuffer(), int64(l.pid), 10))
+		buffer.WriteString("] ")
+	}
+}
+
+// timestamp formats the time for a log line written by this logger, which is the current time unless the line was held
+func (l *Logger) timestamp() string {
+	return string(l.appendTimestamp(nil))
+}
+
+// appendTimestamp appends the time for a log line written by this logger to a byte slice
+func (l *Logger) appendTimestamp(dst []byte) []byte {
+	if !l.at.IsZero() {
+		return l.Logpher.Configuration.appendTime(dst, l.at)
+	}
+	return l.Logpher.Configuration.appendTime(dst, time.Now())
+}
 
-	l.Logpher.Configuration.writer.write(l, level, message)
+// heldAt creates a copy of the logger whose lines are timestamped with the supplied time, for writers that hold lines
+// and write them later
+func (l *Logger) heldAt(at time.Time) *Logger {
+	held := l.With()
+	held.at = at
+	return held
 }
 
 // NewLogger creates a new logger using the autumn Logpher instance configuration
@@ -76,6 +377,7 @@ func (l *Logger) GetLeafName() string {
 
 // PostConstruct initializes the logger when it's used as an autumn leaf
 func (l *Logger) PostConstruct() {
-	l.level = newLevel(l.Logpher.Configuration.getLevel(l.name))
+	l.level = &atomic.Value{}
+	l.level.Store(newLevel(l.Logpher.Configuration.getLevel(l.name)))
 	l.name = strings.ToUpper(l.name)
 }
@@ -0,0 +1,31 @@
+package logpher
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRateLimitSummaryRespectsLevel makes sure the dropped line summary isn't written by a logger that hides warnings
+func TestRateLimitSummaryRespectsLevel(t *testing.T) {
+	writer := NewMemoryWriter(nil)
+	logger := New(&Configuration{Writer: writer, Levels: map[string]string{}}).NewLogger("test")
+	logger.SetLevel(Error)
+	limited := logger.WithRateLimit(1, 20*time.Millisecond, 1)
+
+	// Drop a line, then write another once the limit allows it, which would report the drop
+	limited.Error("first")
+	limited.Error("dropped")
+	time.Sleep(40 * time.Millisecond)
+	limited.Error("second")
+
+	lines := writer.Lines()
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", lines)
+	}
+	for _, line := range lines {
+		if strings.Contains(line, "rate limiting") {
+			t.Errorf("expected no rate limiting warning at the error level, got %q", line)
+		}
+	}
+}
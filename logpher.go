@@ -1,14 +1,55 @@
 package logpher
 
-import "strings"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
 
 // Logpher defines the main logging structure
 type Logpher struct {
 	Configuration *Configuration `autumn:"logConfiguration"`
 }
 
-// New creates a new logpher instance with the supplied configuration
+// New creates a new logpher instance with the supplied configuration, panicking if a writer can't be set up
 func New(configuration *Configuration) *Logpher {
+	l := newLogpher(configuration)
+	l.PostConstruct()
+	return l
+}
+
+// Open validates the supplied configuration and creates a new logpher instance with it, returning an error instead of
+// panicking when the configuration is invalid or a writer can't be set up
+func Open(configuration *Configuration) (*Logpher, error) {
+	if configuration != nil {
+		err := configuration.Validate()
+		if err != nil {
+			return nil, fmt.Errorf("invalid logging configuration: %w", err)
+		}
+	}
+
+	l := newLogpher(configuration)
+	err := l.setup()
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// OpenLogger validates the supplied configuration and creates a logger with the supplied name that writes with it,
+// returning an error when the configuration is invalid or a writer can't be set up
+func OpenLogger(name string, configuration *Config) (*Logger, error) {
+	l, err := Open(configuration)
+	if err != nil {
+		return nil, err
+	}
+	return l.NewLogger(name), nil
+}
+
+// newLogpher creates a logpher instance with the supplied configuration, or the default one, without setting up its
+// writer
+func newLogpher(configuration *Configuration) *Logpher {
 
 	// Initialize with the default configuration
 	l := &Logpher{
@@ -19,9 +60,6 @@ func New(configuration *Configuration) *Logpher {
 	if configuration != nil {
 		l.Configuration = configuration
 	}
-
-	// Apply the configuration
-	l.PostConstruct()
 	return l
 }
 
@@ -30,9 +68,35 @@ func (l *Logpher) NewLogger(name string) *Logger {
 	return newLogger(name, l)
 }
 
+// Flush flushes any buffered log lines
+func (l *Logpher) Flush() {
+	flush(l.Configuration.writer)
+}
+
+// FlushContext flushes any buffered log lines, returning the context's error if it's done before they're flushed, so
+// shutdown can bound how long it waits on a slow disk or network
+func (l *Logpher) FlushContext(ctx context.Context) error {
+	return flushContext(ctx, l.Configuration.writer)
+}
+
+// Rotate immediately rotates the log output, for writers that support it
+func (l *Logpher) Rotate() error {
+	return rotateWriter(l.Configuration.writer)
+}
+
+// Reopen reopens the log output, for writers that support it. Wire it to SIGHUP to cooperate with logrotate
+func (l *Logpher) Reopen() error {
+	return reopenWriter(l.Configuration.writer)
+}
+
+// Sync commits written log lines to stable storage, for writers that support it
+func (l *Logpher) Sync() error {
+	return syncWriter(l.Configuration.writer)
+}
+
 // Close closes the log writer
-func (l *Logpher) Close() {
-	l.Configuration.writer.close()
+func (l *Logpher) Close() error {
+	return l.Configuration.writer.Close()
 }
 
 // GetLeafName gets the autumn leaf name
@@ -40,49 +104,242 @@ func (l *Logpher) GetLeafName() string {
 	return "logpher"
 }
 
-// PostConstruct enables autumn post construct functionality
+// PostConstruct enables autumn post construct functionality, panicking if a writer can't be set up
 func (l *Logpher) PostConstruct() {
-	l.Configuration.writer = l.createWriter(l.Configuration.Type, false)
+	panicOnError(l.setup())
+}
+
+// setup creates the configured writer and wraps it in the configured ring buffer, sampling, deduplication, filters,
+// and async queue
+func (l *Logpher) setup() error {
+	l.Configuration.writer = l.Configuration.Writer
+	if l.Configuration.writer == nil {
+		writer, err := l.createWriter(l.Configuration.Type, false)
+		if err != nil {
+			return fmt.Errorf("failed to set up log writer: %w", err)
+		}
+		l.Configuration.writer = l.withThreshold(l.Configuration.Type, writer)
+	}
+
+	// Hold recent lines in memory until a triggering line when a ring buffer is configured
+	if l.Configuration.Ring > 0 {
+		l.Configuration.writer = newRingWriter(l.Configuration.writer, l.Configuration.Ring, l.ringLevel())
+	}
+
+	// Sample repetitive lines when sampling is configured
+	if l.Configuration.Sample > 0 {
+		c := l.Configuration
+		l.Configuration.writer = newSampleWriter(c.writer, c.Sample, c.Thereafter, c.SampleInterval, sampleKey(c.SampleBy))
+	}
+
+	// Collapse repeated lines when deduplication is configured
+	if l.Configuration.Dedupe > 0 {
+		l.Configuration.writer = newDedupeWriter(l.Configuration.writer, l.Configuration.Dedupe)
+	}
+
+	// Skip lines rejected by the filters, before they reach sampling, deduplication, or the ring buffer
+	if len(l.Configuration.Filters) > 0 {
+		l.Configuration.writer = newFilterWriter(l.Configuration.writer, l.Configuration.Filters)
+	}
+
+	// Decouple callers from the writer when an async queue is configured
+	if l.Configuration.Queue > 0 {
+		l.Configuration.writer = newAsyncWriter(l.Configuration.writer, l.Configuration.Queue, l.Configuration.Overflow, l.Configuration.Metrics, l.Configuration.Grace)
+	}
+	return nil
+}
+
+// ringLevel gets the level that writes out the ring buffer, defaulting to error
+func (l *Logpher) ringLevel() *Level {
+	if l.Configuration.RingLevel == "" {
+		return Error
+	}
+	return newLevel(l.Configuration.RingLevel)
 }
 
 // PreDestroy enables autumn pre destroy functionality
 func (l *Logpher) PreDestroy() {
-	l.Close()
+	_ = l.Close()
+}
+
+// header gets the header line that starts each new file, which is only used by the CSV format
+func (l *Logpher) header() string {
+	if !l.Configuration.CSVHeader || l.Configuration.Formatter != nil || strings.ToLower(l.Configuration.Format) != csvFormat {
+		return ""
+	}
+	return csvHeader(l.Configuration.CSVColumns)
+}
+
+// redacts determines if any field redaction is configured
+func (l *Logpher) redacts() bool {
+	return len(l.Configuration.Redact) > 0 || l.Configuration.RedactValues != nil
+}
+
+// redactor gets the configured field redaction for writers that don't use a formatter, or nil when there isn't any
+func (l *Logpher) redactor() func([]Field) []Field {
+	if !l.redacts() {
+		return nil
+	}
+	return redactor(l.Configuration.Redact, l.Configuration.RedactValues)
+}
+
+// formatter gets the configured line formatter, preferring a custom formatter when one is supplied
+func (l *Logpher) formatter(colour bool) Formatter {
+	format := l.Configuration.Formatter
+	text := false
+	if format == nil {
+		name := strings.ToLower(l.Configuration.Format)
+		format = newFormatter(name, colour, l.Configuration.CSVColumns)
+		text = name != jsonFormat && name != csvFormat
+	}
+
+	if l.redacts() {
+		format = redacting(format, l.Configuration.Redact, l.Configuration.RedactValues)
+	}
+
+	if l.Configuration.MaxLine > 0 {
+		return truncating(format, l.Configuration.MaxLine, text)
+	}
+	return format
 }
 
-// createWriter creates a writer with the supplied type
-func (l *Logpher) createWriter(writerType string, recursive bool) writer {
-	switch strings.ToLower(writerType) {
+// standardFormat determines if lines use the plain standard format, without a custom formatter, redaction, or
+// truncation
+func (l *Logpher) standardFormat() bool {
+	c := l.Configuration
+	name := strings.ToLower(c.Format)
+	plain := name != jsonFormat && name != logfmtFormat && name != csvFormat
+	return plain && c.Formatter == nil && !l.redacts() && c.MaxLine <= 0
+}
+
+// withThreshold wraps a writer in a threshold writer when a minimum level is configured for its type
+func (l *Logpher) withThreshold(writerType string, writer Writer) Writer {
+	threshold, ok := l.Configuration.Thresholds[normalizeType(writerType)]
+	if !ok {
+		return writer
+	}
+	return newThresholdWriter(writer, newLevel(threshold))
+}
+
+// createWriter creates a writer with the supplied type, returning an error if it can't be set up
+func (l *Logpher) createWriter(writerType string, recursive bool) (Writer, error) {
+	switch normalizeType(writerType) {
 	case combination:
 
 		// Prevent infinite recursion when a combination writer is set as a sub type of a combination writer
 		if recursive {
-			panic("a combination writer can only be used at the top level")
+			return nil, errors.New("a combination writer can only be used at the top level")
 		}
 
 		// Split the sub writer string
 		subTypes := strings.Split(l.Configuration.Combine, combinationDelimiter)
 		if len(subTypes) < 1 {
-			panic("please supply some writers to combine")
+			return nil, errors.New("please supply some writers to combine")
 		}
 
-		// Create the sub writers recursively
-		subWriters := make([]writer, len(subTypes))
-		for i, subWriterType := range subTypes {
-			subWriters[i] = l.createWriter(subWriterType, true)
+		// Create the sub writers recursively, closing the ones already created if one fails so they don't leak
+		subWriters := make([]Writer, 0, len(subTypes))
+		for _, subWriterType := range subTypes {
+			subWriter, err := l.createWriter(subWriterType, true)
+			if err != nil {
+				return nil, errors.Join(err, newCombinationWriter(subWriters).Close())
+			}
+			subWriters = append(subWriters, l.withThreshold(subWriterType, subWriter))
 		}
 
-		return newCombinationWriter(subWriters)
+		return newCombinationWriter(subWriters), nil
 
 	case file:
-		return newFileWriter(l.Configuration.File)
+		writer, err := newFileWriter(l.Configuration.File, l.Configuration.SyncWrites, l.header(), l.formatter(false))
+		if err != nil {
+			return nil, err
+		}
+		return writer, nil
 
 	case rolling:
-		return newRollingWriter(l.Configuration.File, l.Configuration.Size, l.Configuration.Count)
+		c := l.Configuration
+		size, err := c.fileSize()
+		if err != nil {
+			return nil, err
+		}
+
+		// Leave the plain standard format to the writer, which builds it straight into its write buffer
+		format := l.formatter(false)
+		if l.standardFormat() {
+			format = nil
+		}
+
+		writer, err := NewRollingWriter(
+			c.File,
+			WithMaxSize(size),
+			WithMaxLines(c.Lines),
+			WithMaxCount(c.fileCount()),
+			WithMaxAge(c.Age),
+			WithMaxTotal(int64(c.Total)*c.megabyte()),
+			WithCompression(c.Compress),
+			WithCompressionLevel(c.gzipLevel()),
+			WithCompressor(c.Compressor),
+			WithCompressExisting(c.CompressOld),
+			WithSyncWrites(c.SyncWrites),
+			WithSyncEvery(c.SyncEvery),
+			WithNumbering(c.Numbered),
+			WithLocalArchiveTime(c.LocalArchive),
+			WithLatestLink(c.Latest),
+			WithArchiveDir(c.Archive),
+			WithInterval(c.Interval),
+			WithFlushInterval(c.Flush),
+			WithWatchInterval(c.Watch),
+			WithDiskFullBackoff(c.Backoff, c.FreeSpace),
+			WithWriteRetries(c.Retries, c.RetryBackoff),
+			WithKeepFailed(c.KeepFailed),
+			WithErrorHandler(c.ErrorHandler),
+			WithOnRotate(c.OnRotate),
+			WithMetrics(c.Metrics),
+			WithSeparator(c.separator()),
+			WithHeader(l.header()),
+			WithRotationMarker(c.Marker),
+			WithFormatter(format),
+		)
+		if err != nil {
+			return nil, err
+		}
+		return writer, nil
+
+	case syslog:
+		c := l.Configuration
+		writer, err := newSyslogWriter(c.SyslogNetwork, c.SyslogAddress, c.SyslogProtocol, c.SyslogTag, l.formatter(false))
+		if err != nil {
+			return nil, err
+		}
+		return writer, nil
+
+	case journald:
+		writer, err := newJournaldWriter(l.Configuration.SyslogTag, l.redactor(), l.Configuration.MaxLine)
+		if err != nil {
+			return nil, err
+		}
+		return writer, nil
+
+	case tcp:
+		c := l.Configuration
+		return newTCPWriter(c.TCPAddress, c.TCPTimeout, c.TCPBuffer, c.TCPTLS, c.ErrorHandler, l.formatter(false)), nil
+
+	case udp:
+		c := l.Configuration
+		writer, err := newUDPWriter(c.UDPAddress, c.UDPSize, c.UDPErrors, l.formatter(false))
+		if err != nil {
+			return nil, err
+		}
+		return writer, nil
+
+	case webhook:
+		c := l.Configuration
+		return newHTTPWriter(c.HTTPURL, c.HTTPHeaders, c.HTTPBatch, c.HTTPInterval, c.HTTPRetries, c.ErrorHandler, l.formatter(false)), nil
 
 	case console:
 		fallthrough
 	default:
-		return newConsoleWriter()
+		stderr := l.Configuration.Stderr
+		return newConsoleWriter(l.formatter(consoleColour(l.Configuration.Colour, stderr)), stderr), nil
 	}
 }
@@ -0,0 +1,66 @@
+package logpher
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestOpenLoggerSpacedCombination makes sure combined writer types are matched the same way they're validated, so a
+// space after the delimiter still creates the writer it names
+func TestOpenLoggerSpacedCombination(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "test.log")
+	config := &Config{Type: "combination", Combine: "console, rolling", File: fileName, Levels: map[string]string{}}
+
+	logger, err := OpenLogger("test", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger.Info("written to the file")
+	err = logger.Logpher.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "written to the file") {
+		t.Errorf("expected the rolling file to contain the line, got %q", data)
+	}
+}
+
+// TestOpenRollingStandardFormat makes sure a configured rolling writer builds standard lines straight into its buffer
+// unless the lines need the formatter
+func TestOpenRollingStandardFormat(t *testing.T) {
+	tests := []struct {
+		config   *Config
+		standard bool
+	}{
+		{&Config{}, true},
+		{&Config{Format: standardFormat}, true},
+		{&Config{Format: jsonFormat}, false},
+		{&Config{MaxLine: 100}, false},
+		{&Config{Redact: []string{"password"}}, false},
+	}
+
+	for i, test := range tests {
+		test.config.Type = rolling
+		test.config.File = filepath.Join(t.TempDir(), "test.log")
+		test.config.Levels = map[string]string{}
+
+		l, err := Open(test.config)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		writer := l.Configuration.writer.(*RollingWriter)
+		if writer.standard != test.standard {
+			t.Errorf("expected standard to be %v for test %d", test.standard, i)
+		}
+		_ = l.Close()
+	}
+}
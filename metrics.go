@@ -0,0 +1,33 @@
+package logpher
+
+// Metrics defines a set of hooks for observing writer activity, such as exporting it to a metrics system. Hooks are
+// called while the writer holds its lock, so they need to be fast and safe for concurrent use
+type Metrics interface {
+	Written(bytes int)       // Called with the size of each line written by the rolling writer
+	Rotated()                // Called after the rolling writer rotates its file
+	Failed(err error)        // Called when the rolling writer fails to write, flush, or rotate
+	Dropped(overflow string) // Called when the async writer drops a line, with the overflow policy that dropped it
+}
+
+// noMetrics defines metrics hooks that do nothing, used when no metrics are configured
+type noMetrics struct{}
+
+// Written does nothing
+func (noMetrics) Written(int) {}
+
+// Rotated does nothing
+func (noMetrics) Rotated() {}
+
+// Failed does nothing
+func (noMetrics) Failed(error) {}
+
+// Dropped does nothing
+func (noMetrics) Dropped(string) {}
+
+// metricsOrDefault gets the supplied metrics hooks, or hooks that do nothing when there aren't any
+func metricsOrDefault(metrics Metrics) Metrics {
+	if metrics == nil {
+		return noMetrics{}
+	}
+	return metrics
+}
@@ -0,0 +1,83 @@
+package prometheus
+
+import (
+	"github.com/miratronix/logpher"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics defines logpher metrics hooks that export writer activity as Prometheus collectors
+type Metrics struct {
+	written prometheus.Counter
+	rotated prometheus.Counter
+	failed  prometheus.Counter
+	dropped *prometheus.CounterVec
+}
+
+// NewPrometheusMetrics creates metrics hooks with collectors in the supplied namespace. Set them as the logpher
+// configuration's Metrics and register them with a Prometheus registry
+func NewPrometheusMetrics(namespace string) *Metrics {
+	return &Metrics{
+		written: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "log_bytes_written_total",
+			Help:      "The number of bytes written to log files.",
+		}),
+		rotated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "log_rotations_total",
+			Help:      "The number of log file rotations.",
+		}),
+		failed: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "log_write_errors_total",
+			Help:      "The number of failed log writes, flushes, and rotations.",
+		}),
+		dropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "log_dropped_lines_total",
+			Help:      "The number of log lines dropped by a full async queue.",
+		}, []string{"overflow"}),
+	}
+}
+
+// Written counts the bytes written
+func (m *Metrics) Written(bytes int) {
+	m.written.Add(float64(bytes))
+}
+
+// Rotated counts a rotation
+func (m *Metrics) Rotated() {
+	m.rotated.Inc()
+}
+
+// Failed counts a failure
+func (m *Metrics) Failed(error) {
+	m.failed.Inc()
+}
+
+// Dropped counts a dropped line by the overflow policy that dropped it
+func (m *Metrics) Dropped(overflow string) {
+	m.dropped.WithLabelValues(overflow).Inc()
+}
+
+// Describe sends the descriptors of the collectors
+func (m *Metrics) Describe(descriptors chan<- *prometheus.Desc) {
+	m.written.Describe(descriptors)
+	m.rotated.Describe(descriptors)
+	m.failed.Describe(descriptors)
+	m.dropped.Describe(descriptors)
+}
+
+// Collect sends the current values of the collectors
+func (m *Metrics) Collect(metrics chan<- prometheus.Metric) {
+	m.written.Collect(metrics)
+	m.rotated.Collect(metrics)
+	m.failed.Collect(metrics)
+	m.dropped.Collect(metrics)
+}
+
+// Make sure the hooks can be used as both logpher metrics and a Prometheus collector
+var (
+	_ logpher.Metrics      = &Metrics{}
+	_ prometheus.Collector = &Metrics{}
+)
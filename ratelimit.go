@@ -0,0 +1,73 @@
+package logpher
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiter defines a token bucket that limits how many lines a logger writes, counting the lines it drops
+type rateLimiter struct {
+	lock     *sync.Mutex
+	tokens   float64
+	burst    float64
+	rate     float64
+	interval time.Duration
+	last     time.Time
+	dropped  int
+	reported time.Time
+}
+
+// newRateLimiter creates a rate limiter allowing the supplied number of lines per interval, with bursts of up to the
+// burst size. The interval defaults to a second, and the bucket starts full
+func newRateLimiter(lines int, interval time.Duration, burst int) *rateLimiter {
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	if burst < lines {
+		burst = lines
+	}
+
+	now := time.Now()
+	return &rateLimiter{
+		lock:     &sync.Mutex{},
+		tokens:   float64(burst),
+		burst:    float64(burst),
+		rate:     float64(lines) / float64(interval),
+		interval: interval,
+		last:     now,
+		reported: now,
+	}
+}
+
+// allow determines if a line can be written, taking a token if it can. When lines have been dropped and an interval
+// has passed since the last report, the number dropped is returned so it can be reported
+func (r *rateLimiter) allow() (bool, int) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	// Refill the bucket for the time that's passed
+	now := time.Now()
+	r.tokens += float64(now.Sub(r.last)) * r.rate
+	if r.tokens > r.burst {
+		r.tokens = r.burst
+	}
+	r.last = now
+
+	// Drop the line if the bucket is empty
+	if r.tokens < 1 {
+		r.dropped++
+		return false, 0
+	}
+	r.tokens--
+
+	// Report the dropped lines at most once an interval
+	if r.dropped == 0 || now.Sub(r.reported) < r.interval {
+		return true, 0
+	}
+
+	dropped := r.dropped
+	r.dropped = 0
+	r.reported = now
+	return true, dropped
+}
@@ -0,0 +1,86 @@
+package logpher
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Size multipliers. Binary units are powers of 1024 and SI units are powers of 1000
+const (
+	kibibyte = 1024
+	mebibyte = 1024 * kibibyte
+	gibibyte = 1024 * mebibyte
+	tebibyte = 1024 * gibibyte
+	kilobyte = 1000
+	megabyte = 1000 * kilobyte
+	gigabyte = 1000 * megabyte
+	terabyte = 1000 * gigabyte
+)
+
+// sizeUnits defines the multipliers for size suffixes, longest first so "MIB" and "MB" are matched before "B". The
+// binary suffixes always mean powers of 1024, while the others depend on whether SI units are used
+var sizeUnits = []struct {
+	suffix string
+	binary float64
+	si     float64
+}{
+	{"TIB", tebibyte, tebibyte},
+	{"GIB", gibibyte, gibibyte},
+	{"MIB", mebibyte, mebibyte},
+	{"KIB", kibibyte, kibibyte},
+	{"TB", tebibyte, terabyte},
+	{"GB", gibibyte, gigabyte},
+	{"MB", mebibyte, megabyte},
+	{"KB", kibibyte, kilobyte},
+	{"T", tebibyte, terabyte},
+	{"G", gibibyte, gigabyte},
+	{"M", mebibyte, megabyte},
+	{"K", kibibyte, kilobyte},
+	{"B", 1, 1},
+}
+
+// ParseSize parses a human readable size such as "500KB", "100MB", "1.5GB", or "10MiB" into bytes. Units are binary,
+// so "1MB" and "1MiB" are both 1,048,576 bytes. Sizes without a unit are treated as bytes
+func ParseSize(size string) (int64, error) {
+	return parseSize(size, false)
+}
+
+// ParseSizeSI parses a human readable size like ParseSize, except that KB, MB, GB, and TB are SI units, so "1MB" is
+// 1,000,000 bytes. KiB, MiB, GiB, and TiB are still binary, so "1MiB" is 1,048,576 bytes
+func ParseSizeSI(size string) (int64, error) {
+	return parseSize(size, true)
+}
+
+// parseSize parses a human readable size into bytes, with SI or binary units
+func parseSize(size string, si bool) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(size))
+
+	// Find the unit multiplier
+	multiplier := 1.0
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(value, unit.suffix) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix))
+			multiplier = unit.binary
+			if si {
+				multiplier = unit.si
+			}
+			break
+		}
+	}
+
+	// Parse the number in front of it
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || number < 0 || math.IsNaN(number) || math.IsInf(number, 0) {
+		return 0, fmt.Errorf("invalid size %q", size)
+	}
+
+	// Make sure the size fits, converting anything too large to an int64 is undefined. MaxInt64 rounds up to 2^63 as a
+	// float, so it's out of range too
+	bytes := number * multiplier
+	if bytes >= math.MaxInt64 {
+		return 0, fmt.Errorf("size %q is too large", size)
+	}
+	return int64(bytes), nil
+}
@@ -0,0 +1,34 @@
+package logpher
+
+import (
+	"testing"
+)
+
+// TestParseSize makes sure sizes parse to the expected number of bytes, and out of range sizes are rejected
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		size     string
+		expected int64
+		valid    bool
+	}{
+		{"100", 100, true},
+		{"500KB", 500 * kibibyte, true},
+		{"1.5GB", 3 * gibibyte / 2, true},
+		{"10MiB", 10 * mebibyte, true},
+		{"", 0, false},
+		{"-1MB", 0, false},
+		{"NaN", 0, false},
+		{"Inf", 0, false},
+		{"1e30GB", 0, false},
+	}
+
+	for _, test := range tests {
+		size, err := ParseSize(test.size)
+		if test.valid && (err != nil || size != test.expected) {
+			t.Errorf("ParseSize(%q) = %d, %v, expected %d", test.size, size, err, test.expected)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("ParseSize(%q) = %d, expected an error", test.size, size)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package logpher
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"runtime"
+	"strconv"
+)
+
+// Handler defines a slog.Handler that writes records through a logger
+type Handler struct {
+	logger *Logger
+	fields []Field
+	prefix string
+}
+
+// NewHandler creates a slog.Handler that writes records through the supplied logger
+func NewHandler(logger *Logger) *Handler {
+	return &Handler{logger: logger}
+}
+
+// Enabled determines if records at the supplied slog level will be written
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.logger.LevelEnabled(slogLevel(level))
+}
+
+// Handle writes a record, converting its attributes into structured fields after any registered context fields
+func (h *Handler) Handle(ctx context.Context, record slog.Record) error {
+	fields := append(fieldsFromContext(ctx), h.fields...)
+	record.Attrs(func(attr slog.Attr) bool {
+		fields = appendAttr(fields, h.prefix, attr)
+		return true
+	})
+
+	// Add the location of the log call, which slog has already captured
+	if h.logger.Logpher.Configuration.Caller && record.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{record.PC}).Next()
+		fields = append([]Field{Any(callerKey, filepath.Base(frame.File)+":"+strconv.Itoa(frame.Line))}, fields...)
+	}
+
+	level := slogLevel(record.Level)
+	if !h.logger.LevelEnabled(level) {
+		return nil
+	}
+
+	// Timestamp the line with the time the record was created, when it has one
+	logger := h.logger
+	if !record.Time.IsZero() {
+		logger = logger.heldAt(record.Time)
+	}
+	logger.write(level, record.Message, fields)
+	return nil
+}
+
+// WithAttrs creates a handler that includes the supplied attributes on every record
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := append([]Field{}, h.fields...)
+	for _, attr := range attrs {
+		fields = appendAttr(fields, h.prefix, attr)
+	}
+
+	return &Handler{logger: h.logger, fields: fields, prefix: h.prefix}
+}
+
+// WithGroup creates a handler that qualifies subsequent attribute keys with the supplied group name
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &Handler{logger: h.logger, fields: h.fields, prefix: h.prefix + name + "."}
+}
+
+// appendAttr appends an attribute to the fields, flattening groups into dotted keys
+func appendAttr(fields []Field, prefix string, attr slog.Attr) []Field {
+	attr.Value = attr.Value.Resolve()
+
+	// Empty attributes are ignored
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	// Flatten groups, inlining the attributes of groups without a key
+	if attr.Value.Kind() == slog.KindGroup {
+		if attr.Key != "" {
+			prefix += attr.Key + "."
+		}
+		for _, groupAttr := range attr.Value.Group() {
+			fields = appendAttr(fields, prefix, groupAttr)
+		}
+		return fields
+	}
+
+	return append(fields, Any(prefix+attr.Key, attr.Value.Any()))
+}
+
+// slogLevel converts a slog level to the closest logging level
+func slogLevel(level slog.Level) *Level {
+	switch {
+	case level < slog.LevelDebug:
+		return Trace
+	case level < slog.LevelInfo:
+		return Debug
+	case level < slog.LevelWarn:
+		return Info
+	case level < slog.LevelError:
+		return Warn
+	default:
+		return Error
+	}
+}
@@ -0,0 +1,26 @@
+package logpher
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestHandlerRecordTime makes sure lines are timestamped with the record's time rather than the time they're written
+func TestHandlerRecordTime(t *testing.T) {
+	writer := NewMemoryWriter(nil)
+	logger := New(&Configuration{Writer: writer, Levels: map[string]string{}}).NewLogger("test")
+
+	at := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	err := NewHandler(logger).Handle(context.Background(), slog.NewRecord(at, slog.LevelInfo, "held", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := writer.Lines()
+	if len(lines) != 1 || !strings.HasPrefix(lines[0], "[2024-03-01T12:00:00Z] [TEST] [INFO] held") {
+		t.Errorf("expected the line to use the record time, got %q", lines)
+	}
+}
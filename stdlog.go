@@ -0,0 +1,24 @@
+package logpher
+
+import (
+	"io"
+	"strings"
+)
+
+// standardWriter defines an io.Writer that writes each call as a log line at a fixed level
+type standardWriter struct {
+	logger *Logger
+	level  *Level
+}
+
+// StandardWriter creates an io.Writer that logs at the supplied level, for use with log.SetOutput or log.New
+func (l *Logger) StandardWriter(level *Level) io.Writer {
+	return &standardWriter{logger: l, level: level}
+}
+
+// Write logs the supplied bytes as a single line, trimming the trailing newline added by the standard logger. The
+// standard logger always calls it through two frames, its print function and its output function
+func (s *standardWriter) Write(data []byte) (int, error) {
+	s.logger.log(3, s.level, strings.TrimSuffix(string(data), "\n"))
+	return len(data), nil
+}
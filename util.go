@@ -1,16 +1,40 @@
 package logpher
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
-	"path/filepath"
-	"time"
+	"sync"
+	"syscall"
 )
 
-const (
-	megabyte = 1024 * 1024
-	format   = "[%s] [%s] [%s] %s"
-)
+const gzipExt = ".gz"
+
+// maxPooledBuffer defines the largest buffer returned to the pool, so one huge line doesn't pin its memory
+const maxPooledBuffer = 64 * 1024
+
+// bufferPool defines a pool of buffers for building log lines without allocating on every line
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return &bytes.Buffer{}
+	},
+}
+
+// getBuffer gets an empty buffer from the pool
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+// putBuffer resets a buffer and returns it to the pool
+func putBuffer(buffer *bytes.Buffer) {
+	if buffer.Cap() > maxPooledBuffer {
+		return
+	}
+	buffer.Reset()
+	bufferPool.Put(buffer)
+}
 
 // panicOnError panics when a non-nil error is supplied
 func panicOnError(err error) {
@@ -19,24 +43,176 @@ func panicOnError(err error) {
 	}
 }
 
-// toAbsolutePath converts a file path to an absolute path, panicking if there are failures
-func toAbsolutePath(path string) string {
-	absolutePath, err := filepath.Abs(path)
-	panicOnError(err)
-	return absolutePath
+// The destination for the package's own diagnostic messages, such as write failures
+var (
+	diagnosticsLock           = &sync.Mutex{}
+	diagnostics     io.Writer = os.Stderr
+)
+
+// SetDiagnostics sets where the package's own diagnostic messages are written, such as write failures when there's
+// no error handler. It defaults to stderr, keeping them separate from the application's stdout, and nil discards them
+func SetDiagnostics(output io.Writer) {
+	if output == nil {
+		output = io.Discard
+	}
+
+	diagnosticsLock.Lock()
+	defer diagnosticsLock.Unlock()
+	diagnostics = output
 }
 
-// openFile opens the supplied file path
-func openFile(path string) (*os.File, error) {
-	return os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+// diagnose writes a diagnostic message
+func diagnose(values ...interface{}) {
+	diagnosticsLock.Lock()
+	defer diagnosticsLock.Unlock()
+	_, _ = fmt.Fprintln(diagnostics, values...)
 }
 
-// formatStandard formats a standard log line, without colouring it
-func formatStandard(logger *Logger, level *level, line string) string {
-	return fmt.Sprintf(format, time.Now().Format(time.RFC3339), logger.name, level.display, line)
+// defaultErrorHandler reports an internal failure as a diagnostic when no error handler is configured
+func defaultErrorHandler(err error) {
+	diagnose(err)
+}
+
+// preserveAttributes applies the mode and, where permitted, the owner of the original file to a new one. Changing the
+// owner usually needs privileges, so failing to is ignored
+func preserveAttributes(file *os.File, original os.FileInfo) error {
+	err := file.Chmod(original.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	uid, gid, ok := fileOwner(original)
+	if ok {
+		_ = file.Chown(uid, gid)
+	}
+	return nil
+}
+
+// discardFile closes and removes a file that's no longer needed after a failure, returning the failure along with any
+// errors cleaning up
+func discardFile(file *os.File, err error) error {
+	return errors.Join(err, file.Close(), os.Remove(file.Name()))
+}
+
+// notDirectory makes sure a log file path isn't an existing directory, which is an easy mistake to make in
+// configuration and otherwise fails with a confusing open error
+func notDirectory(path string) error {
+	info, err := os.Stat(path)
+	if err == nil && info.IsDir() {
+		return fmt.Errorf("log file path is a directory, expected a file path: %s", path)
+	}
+	return nil
+}
+
+// exists determines if the supplied path exists
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// openFile opens the supplied file path for appending, with any extra open flags such as os.O_SYNC
+func openFile(path string, flags int) (*os.File, error) {
+	return os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY|flags, 0644)
+}
+
+// moveFile renames a file, falling back to copying it and removing the original when the destination is on a different
+// filesystem
+func moveFile(source string, destination string) error {
+	err := os.Rename(source, destination)
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	// Open the source file
+	sourceFile, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer sourceFile.Close()
+
+	info, err := sourceFile.Stat()
+	if err != nil {
+		return err
+	}
+
+	// Create the destination file with the same mode, never overwriting an existing one
+	destinationFile, err := os.OpenFile(destination, os.O_CREATE|os.O_EXCL|os.O_WRONLY, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	// Copy the contents and make sure they're on disk before the original is removed
+	_, err = io.Copy(destinationFile, sourceFile)
+	if err == nil {
+		err = destinationFile.Sync()
+	}
+	err = errors.Join(err, destinationFile.Close())
+	if err != nil {
+		_ = os.Remove(destination)
+		return err
+	}
+
+	// Remove the original
+	_ = sourceFile.Close()
+	return os.Remove(source)
 }
 
-// formatColour formats a log line with colour information
-func formatColour(logger *Logger, level *level, line string) string {
-	return level.colourizer(format, time.Now().Format(time.RFC3339), logger.name, level.display, line)
+// compressFile compresses the supplied file into a new file with the compressor's extension and removes the original
+func compressFile(path string, compressor Compressor) error {
+
+	// Open the source file
+	source, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	info, err := source.Stat()
+	if err != nil {
+		return err
+	}
+
+	// Create the compressed file with the same mode and owner, so compressing doesn't make it readable to anyone else
+	compressed := path + compressor.Extension()
+	destination, err := os.OpenFile(compressed, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	err = preserveAttributes(destination, info)
+	if err != nil {
+		return discardFile(destination, err)
+	}
+
+	// Copy the source into the compressing writer
+	writer, err := compressor.NewWriter(destination)
+	if err != nil {
+		_ = destination.Close()
+		_ = os.Remove(compressed)
+		return err
+	}
+
+	_, err = io.Copy(writer, source)
+	if err != nil {
+		_ = destination.Close()
+		_ = os.Remove(compressed)
+		return err
+	}
+
+	// Close the compressing writer first so the footer is flushed to the file
+	err = writer.Close()
+	if err != nil {
+		_ = destination.Close()
+		_ = os.Remove(compressed)
+		return err
+	}
+
+	err = destination.Close()
+	if err != nil {
+		return err
+	}
+
+	// Remove the uncompressed original
+	_ = source.Close()
+	return os.Remove(path)
 }
@@ -0,0 +1,18 @@
+//go:build !windows
+// +build !windows
+
+package logpher
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileOwner gets the user and group IDs that own a file
+func fileOwner(info os.FileInfo) (int, int, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, false
+	}
+	return int(stat.Uid), int(stat.Gid), true
+}
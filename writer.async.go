@@ -0,0 +1,226 @@
+package logpher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Overflow policies for the async writer
+const (
+	overflowBlock      = "block"
+	overflowDropOldest = "drop-oldest"
+	overflowDropNewest = "drop-newest"
+)
+
+// asyncEntry defines a queued log line, or a flush request when flushed is set
+type asyncEntry struct {
+	logger  *Logger
+	level   *Level
+	line    string
+	fields  []Field
+	flushed chan struct{}
+}
+
+// asyncWriter defines a writer that queues log lines and writes them to an underlying writer from a separate goroutine
+type asyncWriter struct {
+	lock      *sync.Mutex
+	closed    bool
+	writer    Writer
+	queue     chan *asyncEntry
+	overflow  string
+	metrics   Metrics
+	grace     time.Duration
+	dropped   *atomic.Int64
+	abandoned *atomic.Bool
+	done      chan struct{}
+}
+
+// newAsyncWriter creates a new async writer with the supplied queue size, overflow policy, and metrics hooks. The grace
+// period limits how long closing waits for the queue to drain, it waits indefinitely when zero
+func newAsyncWriter(writer Writer, size int, overflow string, metrics Metrics, grace time.Duration) *asyncWriter {
+	a := &asyncWriter{
+		lock:      &sync.Mutex{},
+		writer:    writer,
+		queue:     make(chan *asyncEntry, size),
+		overflow:  strings.ToLower(overflow),
+		metrics:   metricsOrDefault(metrics),
+		grace:     grace,
+		dropped:   &atomic.Int64{},
+		abandoned: &atomic.Bool{},
+		done:      make(chan struct{}),
+	}
+
+	go a.drain()
+	return a
+}
+
+// drain writes queued lines to the underlying writer until the queue is closed. Once the queue has been abandoned the
+// remaining lines are discarded
+func (a *asyncWriter) drain() {
+	for entry := range a.queue {
+		if entry.flushed != nil {
+			flush(a.writer)
+			close(entry.flushed)
+			continue
+		}
+
+		if !a.abandoned.Load() {
+			a.writer.Write(entry.logger, entry.level, entry.line, entry.fields)
+		}
+	}
+	close(a.done)
+}
+
+// drop counts a line dropped by the overflow policy
+func (a *asyncWriter) drop() {
+	a.dropped.Add(1)
+	a.metrics.Dropped(a.overflow)
+}
+
+// Dropped gets the number of lines dropped because the queue was full
+func (a *asyncWriter) Dropped() int64 {
+	return a.dropped.Load()
+}
+
+// Write queues a log line, applying the overflow policy if the queue is full
+func (a *asyncWriter) Write(logger *Logger, level *Level, line string, fields []Field) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if a.closed {
+		return
+	}
+
+	entry := &asyncEntry{logger: logger, level: level, line: line, fields: fields}
+	switch a.overflow {
+	case overflowDropNewest:
+		select {
+		case a.queue <- entry:
+		default:
+			a.drop()
+		}
+
+	case overflowDropOldest:
+		for {
+			select {
+			case a.queue <- entry:
+				return
+			default:
+			}
+
+			// Make room by discarding the oldest queued line. Flush requests are never discarded, and everything ahead
+			// of them has already been drained, so they can be served right away
+			select {
+			case oldest := <-a.queue:
+				if oldest.flushed != nil {
+					flush(a.writer)
+					close(oldest.flushed)
+				} else {
+					a.drop()
+				}
+			default:
+			}
+		}
+
+	case overflowBlock:
+		fallthrough
+	default:
+		a.queue <- entry
+	}
+}
+
+// Flush waits for the queued lines to be written, then flushes the underlying writer
+func (a *asyncWriter) Flush() {
+	a.lock.Lock()
+	if a.closed {
+		a.lock.Unlock()
+		return
+	}
+
+	flushed := make(chan struct{})
+	a.queue <- &asyncEntry{flushed: flushed}
+	a.lock.Unlock()
+
+	<-flushed
+}
+
+// FlushContext waits for the queued lines to be written, then flushes the underlying writer, returning early with the
+// context's error if it's done first. The queued lines are still written after it returns early
+func (a *asyncWriter) FlushContext(ctx context.Context) error {
+	a.lock.Lock()
+	if a.closed {
+		a.lock.Unlock()
+		return nil
+	}
+
+	// Queueing the request can block when the queue is full
+	flushed := make(chan struct{})
+	select {
+	case a.queue <- &asyncEntry{flushed: flushed}:
+	case <-ctx.Done():
+		a.lock.Unlock()
+		return ctx.Err()
+	}
+	a.lock.Unlock()
+
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Rotate waits for the queued lines to be written, then rotates the underlying writer
+func (a *asyncWriter) Rotate() error {
+	a.Flush()
+	return rotateWriter(a.writer)
+}
+
+// Reopen waits for the queued lines to be written, then reopens the underlying writer
+func (a *asyncWriter) Reopen() error {
+	a.Flush()
+	return reopenWriter(a.writer)
+}
+
+// Sync waits for the queued lines to be written, then syncs the underlying writer
+func (a *asyncWriter) Sync() error {
+	a.Flush()
+	return syncWriter(a.writer)
+}
+
+// Close drains the queue and closes the underlying writer. When the queue doesn't drain within the grace period the
+// remaining lines are abandoned and reported in the returned error
+func (a *asyncWriter) Close() error {
+	a.lock.Lock()
+	if a.closed {
+		a.lock.Unlock()
+		return nil
+	}
+
+	a.closed = true
+	close(a.queue)
+	a.lock.Unlock()
+
+	if a.grace <= 0 {
+		<-a.done
+		return a.writer.Close()
+	}
+
+	timer := time.NewTimer(a.grace)
+	defer timer.Stop()
+
+	select {
+	case <-a.done:
+		return a.writer.Close()
+	case <-timer.C:
+		a.abandoned.Store(true)
+		err := fmt.Errorf("abandoned %d queued log lines after %s", len(a.queue), a.grace)
+		return errors.Join(err, a.writer.Close())
+	}
+}
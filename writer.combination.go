@@ -1,29 +1,50 @@
 package logpher
 
 import (
+	"errors"
+	"fmt"
 	"sync"
 )
 
 // combinationDelimiter defines the delimiter to use for combination writers
 const combinationDelimiter = ","
 
+// MultiWriter creates a writer that writes each line to all of the supplied writers
+func MultiWriter(writers ...Writer) Writer {
+	return newCombinationWriter(writers)
+}
+
 // combinationWriter defines a simple writer that combines multiple writers into one
 type combinationWriter struct {
 	lock    *sync.Mutex
 	closed  bool
-	writers []writer
+	writers []Writer
 }
 
 // newCombinationWriter creates a new combination writer
-func newCombinationWriter(writers []writer) *combinationWriter {
+func newCombinationWriter(writers []Writer) *combinationWriter {
 	return &combinationWriter{
 		lock:    &sync.Mutex{},
 		writers: writers,
 	}
 }
 
-// write writes a log line each underlying writer
-func (c *combinationWriter) write(logger *Logger, level *level, line string) {
+// Write writes a log line each underlying writer
+func (c *combinationWriter) Write(logger *Logger, level *Level, line string, fields []Field) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.closed {
+		return
+	}
+
+	for _, writer := range c.writers {
+		writeSafely(writer, logger, level, line, fields)
+	}
+}
+
+// Flush flushes each underlying writer
+func (c *combinationWriter) Flush() {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
@@ -32,17 +53,87 @@ func (c *combinationWriter) write(logger *Logger, level *level, line string) {
 	}
 
 	for _, writer := range c.writers {
-		writer.write(logger, level, line)
+		flush(writer)
+	}
+}
+
+// Rotate rotates each underlying writer
+func (c *combinationWriter) Rotate() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.closed {
+		return errClosed
+	}
+
+	var errs []error
+	for _, writer := range c.writers {
+		errs = append(errs, rotateWriter(writer))
 	}
+	return errors.Join(errs...)
+}
+
+// Reopen reopens each underlying writer
+func (c *combinationWriter) Reopen() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.closed {
+		return errClosed
+	}
+
+	var errs []error
+	for _, writer := range c.writers {
+		errs = append(errs, reopenWriter(writer))
+	}
+	return errors.Join(errs...)
+}
+
+// Sync syncs each underlying writer
+func (c *combinationWriter) Sync() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.closed {
+		return nil
+	}
+
+	var errs []error
+	for _, writer := range c.writers {
+		errs = append(errs, syncWriter(writer))
+	}
+	return errors.Join(errs...)
+}
+
+// writeSafely writes a line to a writer, recovering if it panics so the remaining writers still receive the line
+func writeSafely(writer Writer, logger *Logger, level *Level, line string, fields []Field) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			diagnose("Failed to write log line:", recovered)
+		}
+	}()
+	writer.Write(logger, level, line, fields)
+}
+
+// closeSafely closes a writer, converting a panic into an error so the remaining writers are still closed
+func closeSafely(writer Writer) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("failed to close writer: %v", recovered)
+		}
+	}()
+	return writer.Close()
 }
 
-// close closes the writer
-func (c *combinationWriter) close() {
+// Close closes the writer
+func (c *combinationWriter) Close() error {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
+	var errs []error
 	for _, writer := range c.writers {
-		writer.close()
+		errs = append(errs, closeSafely(writer))
 	}
 	c.closed = true
+	return errors.Join(errs...)
 }
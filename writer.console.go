@@ -2,36 +2,83 @@ package logpher
 
 import (
 	"fmt"
+	"github.com/fatih/color"
+	"io"
+	"os"
+	"strings"
 	"sync"
 )
 
+// Console colour modes
+const (
+	colourAuto   = "auto"
+	colourAlways = "always"
+	colourNever  = "never"
+)
+
 // consoleWriter defines a basic console based writer
 type consoleWriter struct {
 	lock   *sync.Mutex
 	closed bool
+	output io.Writer
+	format Formatter
 }
 
-// newConsoleWriter creates a new console based writer
-func newConsoleWriter() *consoleWriter {
+// newConsoleWriter creates a new console based writer, writing to stderr instead of stdout when requested
+func newConsoleWriter(format Formatter, stderr bool) *consoleWriter {
+	output := color.Output
+	if stderr {
+		output = color.Error
+	}
+
 	return &consoleWriter{
-		lock: &sync.Mutex{},
+		lock:   &sync.Mutex{},
+		output: output,
+		format: format,
+	}
+}
+
+// consoleColour determines if console lines should be coloured. In auto mode colours are only used when the output
+// is a terminal
+func consoleColour(mode string, stderr bool) bool {
+	switch strings.ToLower(mode) {
+	case colourAlways:
+		return true
+
+	case colourNever:
+		return false
+
+	case colourAuto:
+		fallthrough
+	default:
+		file := os.Stdout
+		if stderr {
+			file = os.Stderr
+		}
+
+		info, err := file.Stat()
+		if err != nil {
+			return false
+		}
+		return info.Mode()&os.ModeCharDevice != 0 && os.Getenv("TERM") != "dumb"
 	}
 }
 
-// write writes a log line to the console
-func (c *consoleWriter) write(logger *Logger, level *level, line string) {
+// Write writes a log line to the console
+func (c *consoleWriter) Write(logger *Logger, level *Level, line string, fields []Field) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
 	if !c.closed {
-		fmt.Println(formatColour(logger, level, line))
+		_, _ = fmt.Fprintln(c.output, c.format(logger, level, line, fields))
 	}
 }
 
-// close closes the writer
-func (c *consoleWriter) close() {
+// Close closes the writer
+func (c *consoleWriter) Close() error {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
 	c.closed = true
+	return nil
 }
@@ -0,0 +1,135 @@
+package logpher
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// lineEntry defines a log line held by a writer to be written later
+type lineEntry struct {
+	logger *Logger
+	level  *Level
+	line   string
+	fields []Field
+}
+
+// dedupeWriter defines a writer that collapses runs of identical consecutive lines into one line, followed by a
+// summary of how many times it was repeated
+type dedupeWriter struct {
+	lock    *sync.Mutex
+	closed  bool
+	writer  Writer
+	timeout time.Duration
+	last    *lineEntry
+	repeats int
+	timer   *time.Timer
+	run     int // Counts the timers started, so a timer that fired late can tell it's been replaced
+}
+
+// newDedupeWriter creates a new dedupe writer. Repeats are summarized when a different line is written, or once the
+// timeout has passed since the first repeat
+func newDedupeWriter(writer Writer, timeout time.Duration) *dedupeWriter {
+	return &dedupeWriter{
+		lock:    &sync.Mutex{},
+		writer:  writer,
+		timeout: timeout,
+	}
+}
+
+// Write writes a log line unless it repeats the last one, which only counts it. Lines are compared by logger, level,
+// and message, ignoring the timestamp and fields
+func (d *dedupeWriter) Write(logger *Logger, level *Level, line string, fields []Field) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.closed {
+		return
+	}
+
+	// Count repeats, summarizing them after the timeout even if the line never changes
+	if d.last != nil && d.last.logger.name == logger.name && d.last.level == level && d.last.line == line {
+		d.repeats++
+		if d.timer == nil {
+			d.run++
+			run := d.run
+			d.timer = time.AfterFunc(d.timeout, func() { d.expire(run) })
+		}
+		return
+	}
+
+	d.summarize()
+	d.writer.Write(logger, level, line, fields)
+	d.last = &lineEntry{logger: logger, level: level, line: line, fields: fields}
+}
+
+// summarize writes the repeat summary for the last line if it was repeated
+func (d *dedupeWriter) summarize() {
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+
+	if d.repeats == 0 {
+		return
+	}
+
+	last := d.last
+	d.writer.Write(last.logger, last.level, fmt.Sprintf("%s (repeated %d times)", last.line, d.repeats), last.fields)
+	d.repeats = 0
+}
+
+// expire summarizes the repeats once the timeout has passed, unless the run the timer was started for has already been
+// summarized and a newer one started
+func (d *dedupeWriter) expire(run int) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if !d.closed && run == d.run && d.timer != nil {
+		d.summarize()
+	}
+}
+
+// Flush summarizes any repeats and flushes the underlying writer
+func (d *dedupeWriter) Flush() {
+	d.lock.Lock()
+	if !d.closed {
+		d.summarize()
+	}
+	d.lock.Unlock()
+
+	flush(d.writer)
+}
+
+// Rotate summarizes any repeats and rotates the underlying writer
+func (d *dedupeWriter) Rotate() error {
+	d.Flush()
+	return rotateWriter(d.writer)
+}
+
+// Reopen summarizes any repeats and reopens the underlying writer
+func (d *dedupeWriter) Reopen() error {
+	d.Flush()
+	return reopenWriter(d.writer)
+}
+
+// Sync summarizes any repeats and syncs the underlying writer
+func (d *dedupeWriter) Sync() error {
+	d.Flush()
+	return syncWriter(d.writer)
+}
+
+// Close summarizes any repeats and closes the underlying writer
+func (d *dedupeWriter) Close() error {
+	d.lock.Lock()
+	if d.closed {
+		d.lock.Unlock()
+		return nil
+	}
+
+	d.summarize()
+	d.closed = true
+	d.lock.Unlock()
+
+	return d.writer.Close()
+}
@@ -1,8 +1,9 @@
 package logpher
 
 import (
-	"fmt"
+	"errors"
 	"os"
+	"path/filepath"
 	"sync"
 )
 
@@ -11,21 +12,68 @@ type fileWriter struct {
 	lock   *sync.Mutex
 	closed bool
 	file   *os.File
+	path   string
+	flags  int
+	header string
+	format Formatter
 }
 
-// newFileWriter creates a new file based logger
-func newFileWriter(path string) *fileWriter {
-	file, err := openFile(toAbsolutePath(path))
-	panicOnError(err)
+// newFileWriter creates a new file based logger, syncing every write to disk when syncWrites is set. The header is
+// written when the file is empty, unless it's empty too
+func newFileWriter(path string, syncWrites bool, header string, format Formatter) (*fileWriter, error) {
+	flags := 0
+	if syncWrites {
+		flags = os.O_SYNC
+	}
+
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
 
-	return &fileWriter{
-		lock: &sync.Mutex{},
-		file: file,
+	err = notDirectory(path)
+	if err != nil {
+		return nil, err
 	}
+
+	file, err := openFile(path, flags)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &fileWriter{
+		lock:   &sync.Mutex{},
+		file:   file,
+		path:   path,
+		flags:  flags,
+		header: header,
+		format: format,
+	}
+
+	err = f.writeHeader()
+	if err != nil {
+		return nil, errors.Join(err, file.Close())
+	}
+	return f, nil
+}
+
+// writeHeader writes the header to the file if there is one and the file is empty
+func (f *fileWriter) writeHeader() error {
+	if f.header == "" {
+		return nil
+	}
+
+	info, err := f.file.Stat()
+	if err != nil || info.Size() > 0 {
+		return err
+	}
+
+	_, err = f.file.WriteString(f.header + "\n")
+	return err
 }
 
-// write writes a line to the file
-func (f *fileWriter) write(logger *Logger, level *level, line string) {
+// Write writes a line to the file
+func (f *fileWriter) Write(logger *Logger, level *Level, line string, fields []Field) {
 	f.lock.Lock()
 	defer f.lock.Unlock()
 
@@ -33,17 +81,57 @@ func (f *fileWriter) write(logger *Logger, level *level, line string) {
 		return
 	}
 
-	_, err := f.file.WriteString(formatStandard(logger, level, line) + "\n")
+	_, err := f.file.WriteString(f.format(logger, level, line, fields) + "\n")
+	if err != nil {
+		diagnose("Failed to write log line:", err)
+	}
+}
+
+// Reopen closes and reopens the file by name, so lines go to a new file once it's been moved by an external rotation
+// tool
+func (f *fileWriter) Reopen() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.closed {
+		return errClosed
+	}
+
+	// Open the new file first, so a failure leaves the old one in place for the lines that follow
+	file, err := openFile(f.path, f.flags)
+	if err != nil {
+		return err
+	}
+
+	// Close the previous file, moving on to the new one even if that fails
+	err = f.file.Close()
+	f.file = file
 	if err != nil {
-		fmt.Println("Failed to write log line:", err)
+		return err
 	}
+	return f.writeHeader()
 }
 
-// close closes the file writer
-func (f *fileWriter) close() {
+// Sync commits the written lines to stable storage
+func (f *fileWriter) Sync() error {
 	f.lock.Lock()
 	defer f.lock.Unlock()
 
-	f.file.Close()
+	if f.closed {
+		return nil
+	}
+	return f.file.Sync()
+}
+
+// Close syncs and closes the file writer
+func (f *fileWriter) Close() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.closed {
+		return nil
+	}
+
 	f.closed = true
+	return errors.Join(f.file.Sync(), f.file.Close())
 }
@@ -0,0 +1,58 @@
+package logpher
+
+// Filter defines a predicate that decides if a log line should be written
+type Filter func(logger *Logger, level *Level, line string) bool
+
+// FilterWriter creates a writer that only passes lines accepted by all of the supplied filters to the supplied writer
+func FilterWriter(writer Writer, filters ...Filter) Writer {
+	return newFilterWriter(writer, filters)
+}
+
+// filterWriter defines a writer that skips lines rejected by any of its filters before they reach the underlying writer
+type filterWriter struct {
+	writer  Writer
+	filters []Filter
+}
+
+// newFilterWriter creates a new filter writer
+func newFilterWriter(writer Writer, filters []Filter) *filterWriter {
+	return &filterWriter{
+		writer:  writer,
+		filters: filters,
+	}
+}
+
+// Write writes a log line to the underlying writer if every filter accepts it
+func (f *filterWriter) Write(logger *Logger, level *Level, line string, fields []Field) {
+	for _, filter := range f.filters {
+		if !filter(logger, level, line) {
+			return
+		}
+	}
+	f.writer.Write(logger, level, line, fields)
+}
+
+// Flush flushes the underlying writer
+func (f *filterWriter) Flush() {
+	flush(f.writer)
+}
+
+// Rotate rotates the underlying writer
+func (f *filterWriter) Rotate() error {
+	return rotateWriter(f.writer)
+}
+
+// Reopen reopens the underlying writer
+func (f *filterWriter) Reopen() error {
+	return reopenWriter(f.writer)
+}
+
+// Sync syncs the underlying writer
+func (f *filterWriter) Sync() error {
+	return syncWriter(f.writer)
+}
+
+// Close closes the underlying writer
+func (f *filterWriter) Close() error {
+	return f.writer.Close()
+}
@@ -1,14 +1,104 @@
 package logpher
 
+import (
+	"context"
+	"errors"
+)
+
+// errClosed is returned when operating on a writer that has been closed
+var errClosed = errors.New("log writer is closed")
+
 const (
 	console     = "console"
 	file        = "file"
 	rolling     = "rolling"
+	syslog      = "syslog"
+	journald    = "journald"
+	tcp         = "tcp"
+	udp         = "udp"
+	webhook     = "http"
 	combination = "combination"
 )
 
-// writer defines a basic log writer interface
-type writer interface {
-	write(logger *Logger, level *level, line string)
-	close()
+// Writer defines a basic log writer interface
+type Writer interface {
+	Write(logger *Logger, level *Level, line string, fields []Field)
+	Close() error
+}
+
+// Flusher defines a writer that buffers log lines and can flush them on request
+type Flusher interface {
+	Flush()
+}
+
+// ContextFlusher defines a writer that can flush buffered log lines while respecting a context's deadline
+type ContextFlusher interface {
+	FlushContext(ctx context.Context) error
+}
+
+// Rotator defines a writer that can rotate its output on request
+type Rotator interface {
+	Rotate() error
+}
+
+// Reopener defines a writer that can reopen its output, for cooperating with external log rotation
+type Reopener interface {
+	Reopen() error
+}
+
+// Syncer defines a writer that can commit written log lines to stable storage on request
+type Syncer interface {
+	Sync() error
+}
+
+// flush flushes the supplied writer if it buffers log lines
+func flush(writer Writer) {
+	if flusher, ok := writer.(Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// flushContext flushes the supplied writer, returning the context's error if it's done before the flush finishes.
+// Writers that can't stop flushing part way keep flushing in the background
+func flushContext(ctx context.Context, writer Writer) error {
+	if flusher, ok := writer.(ContextFlusher); ok {
+		return flusher.FlushContext(ctx)
+	}
+
+	flushed := make(chan struct{})
+	go func() {
+		flush(writer)
+		close(flushed)
+	}()
+
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// rotateWriter rotates the supplied writer if it supports it
+func rotateWriter(writer Writer) error {
+	if rotator, ok := writer.(Rotator); ok {
+		return rotator.Rotate()
+	}
+	return nil
+}
+
+// reopenWriter reopens the supplied writer if it supports it
+func reopenWriter(writer Writer) error {
+	if reopener, ok := writer.(Reopener); ok {
+		return reopener.Reopen()
+	}
+	return nil
+}
+
+// syncWriter syncs the supplied writer to stable storage if it supports it
+func syncWriter(writer Writer) error {
+	if syncer, ok := writer.(Syncer); ok {
+		return syncer.Sync()
+	}
+	return nil
 }
@@ -0,0 +1,234 @@
+package logpher
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultHTTPBatch    = 100
+	defaultHTTPInterval = time.Second
+	defaultHTTPRetries  = 3
+	defaultHTTPTimeout  = 10 * time.Second
+	minHTTPBackoff      = 500 * time.Millisecond
+)
+
+// httpBatch defines a batch of formatted lines to post, with an optional channel that's closed once it's been sent
+type httpBatch struct {
+	lines []string
+	sent  chan struct{}
+}
+
+// httpWriter defines a writer that posts batches of log lines to a URL
+type httpWriter struct {
+	lock         *sync.Mutex
+	closed       bool
+	url          string
+	headers      map[string]string
+	client       *http.Client
+	lines        []string
+	batchSize    int
+	interval     time.Duration
+	retries      int
+	batches      chan *httpBatch
+	done         chan struct{}
+	failed       int   // The number of lines that couldn't be sent
+	err          error // The last send failure
+	errorHandler func(error)
+	format       Formatter
+}
+
+// newHTTPWriter creates a new HTTP writer. Lines are posted as a newline separated body whenever a batch fills up or
+// the flush interval elapses. Send failures go to the error handler
+func newHTTPWriter(url string, headers map[string]string, batchSize int, interval time.Duration, retries int, errorHandler func(error), format Formatter) *httpWriter {
+	if batchSize <= 0 {
+		batchSize = defaultHTTPBatch
+	}
+
+	if interval <= 0 {
+		interval = defaultHTTPInterval
+	}
+
+	if retries == 0 {
+		retries = defaultHTTPRetries
+	} else if retries < 0 {
+		retries = 0
+	}
+
+	h := &httpWriter{
+		lock:         &sync.Mutex{},
+		url:          url,
+		headers:      headers,
+		client:       &http.Client{Timeout: defaultHTTPTimeout},
+		batchSize:    batchSize,
+		interval:     interval,
+		retries:      retries,
+		batches:      make(chan *httpBatch, 16),
+		done:         make(chan struct{}),
+		errorHandler: errorHandler,
+		format:       format,
+	}
+
+	go h.run()
+	go h.flushPeriodically()
+	return h
+}
+
+// run sends queued batches until the writer is closed
+func (h *httpWriter) run() {
+	for batch := range h.batches {
+		h.send(batch)
+	}
+	close(h.done)
+}
+
+// flushPeriodically queues the current batch whenever the flush interval elapses, until the writer is closed
+func (h *httpWriter) flushPeriodically() {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		h.lock.Lock()
+		if h.closed {
+			h.lock.Unlock()
+			return
+		}
+
+		if len(h.lines) > 0 {
+			h.batches <- &httpBatch{lines: h.lines}
+			h.lines = nil
+		}
+		h.lock.Unlock()
+	}
+}
+
+// send posts a batch, retrying with backoff until it succeeds or the retries are exhausted
+func (h *httpWriter) send(batch *httpBatch) {
+	if batch.sent != nil {
+		defer close(batch.sent)
+	}
+
+	if len(batch.lines) == 0 {
+		return
+	}
+
+	body := []byte(strings.Join(batch.lines, "\n") + "\n")
+	backoff := minHTTPBackoff
+
+	var err error
+	for attempt := 0; attempt <= h.retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+
+		err = h.post(body)
+		if err == nil {
+			return
+		}
+	}
+
+	h.lock.Lock()
+	h.failed += len(batch.lines)
+	h.err = err
+	h.lock.Unlock()
+	h.handleError(fmt.Errorf("failed to send %d log lines: %w", len(batch.lines), err))
+}
+
+// handleError reports a failure using the error handler, printing it when there isn't one
+func (h *httpWriter) handleError(err error) {
+	if h.errorHandler != nil {
+		h.errorHandler(err)
+		return
+	}
+	defaultErrorHandler(err)
+}
+
+// post makes a single request with the supplied body
+func (h *httpWriter) post(body []byte) error {
+	request, err := http.NewRequest(http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	for key, value := range h.headers {
+		request.Header.Set(key, value)
+	}
+
+	response, err := h.client.Do(request)
+	if err != nil {
+		return err
+	}
+
+	// Read the rest of the body before closing it, so the connection can be reused
+	_, _ = io.Copy(io.Discard, response.Body)
+	_ = response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status: %s", response.Status)
+	}
+	return nil
+}
+
+// Write adds a log line to the current batch, queueing the batch once it's full
+func (h *httpWriter) Write(logger *Logger, level *Level, line string, fields []Field) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.closed {
+		return
+	}
+
+	h.lines = append(h.lines, h.format(logger, level, line, fields))
+	if len(h.lines) >= h.batchSize {
+		h.batches <- &httpBatch{lines: h.lines}
+		h.lines = nil
+	}
+}
+
+// Flush sends the current batch and waits for it to be posted
+func (h *httpWriter) Flush() {
+	h.lock.Lock()
+	if h.closed {
+		h.lock.Unlock()
+		return
+	}
+
+	batch := &httpBatch{lines: h.lines, sent: make(chan struct{})}
+	h.lines = nil
+	h.batches <- batch
+	h.lock.Unlock()
+
+	<-batch.sent
+}
+
+// Close sends the current batch and stops the writer once everything queued has been posted, returning an error if any
+// lines couldn't be sent
+func (h *httpWriter) Close() error {
+	h.lock.Lock()
+	if h.closed {
+		h.lock.Unlock()
+		return nil
+	}
+
+	h.batches <- &httpBatch{lines: h.lines}
+	h.lines = nil
+	h.closed = true
+	close(h.batches)
+	h.lock.Unlock()
+
+	<-h.done
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if h.failed > 0 {
+		return fmt.Errorf("failed to send %d log lines in total, last error: %w", h.failed, h.err)
+	}
+	return nil
+}
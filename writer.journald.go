@@ -0,0 +1,143 @@
+package logpher
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// journaldSocket defines the path of the systemd journal's native protocol socket
+const journaldSocket = "/run/systemd/journal/socket"
+
+// journaldWriter defines a writer that sends log lines to the systemd journal using its native protocol, with
+// structured fields as journal fields
+type journaldWriter struct {
+	lock    *sync.Mutex
+	closed  bool
+	conn    net.Conn
+	tag     string
+	redact  func([]Field) []Field
+	maxLine int
+}
+
+// newJournaldWriter creates a new journald writer, returning an error if the journal socket isn't available. Fields
+// are redacted with the supplied function when there is one, and messages longer than the maximum line length are
+// truncated when it's positive
+func newJournaldWriter(tag string, redact func([]Field) []Field, maxLine int) (*journaldWriter, error) {
+	if tag == "" {
+		tag = filepath.Base(os.Args[0])
+	}
+
+	if !exists(journaldSocket) {
+		return nil, fmt.Errorf("the systemd journal socket %s doesn't exist, journald is only available under systemd", journaldSocket)
+	}
+
+	conn, err := net.Dial("unixgram", journaldSocket)
+	if err != nil {
+		return nil, err
+	}
+
+	return &journaldWriter{
+		lock:    &sync.Mutex{},
+		conn:    conn,
+		tag:     tag,
+		redact:  redact,
+		maxLine: maxLine,
+	}, nil
+}
+
+// message builds a native protocol journal entry for a log line
+func (j *journaldWriter) message(logger *Logger, level *Level, line string, fields []Field) []byte {
+	if j.redact != nil {
+		fields = j.redact(fields)
+	}
+	if j.maxLine > 0 && len(line) > j.maxLine {
+		line = truncate(line, j.maxLine-len(truncated)) + truncated
+	}
+
+	buffer := &bytes.Buffer{}
+	appendJournalField(buffer, "MESSAGE", line)
+	appendJournalField(buffer, "PRIORITY", strconv.Itoa(syslogSeverity(level)))
+	appendJournalField(buffer, "SYSLOG_IDENTIFIER", j.tag)
+	appendJournalField(buffer, "LOGGER", logger.name)
+
+	for _, field := range append(logger.process(), fields...) {
+		appendJournalField(buffer, journalKey(field.Key), fmt.Sprint(field.Value))
+	}
+	return buffer.Bytes()
+}
+
+// appendJournalField appends a field to a journal entry. Values containing newlines are written with an explicit
+// length instead of being newline terminated
+func appendJournalField(buffer *bytes.Buffer, key string, value string) {
+	buffer.WriteString(key)
+	if !strings.Contains(value, "\n") {
+		buffer.WriteString("=")
+		buffer.WriteString(value)
+		buffer.WriteString("\n")
+		return
+	}
+
+	buffer.WriteString("\n")
+	_ = binary.Write(buffer, binary.LittleEndian, uint64(len(value)))
+	buffer.WriteString(value)
+	buffer.WriteString("\n")
+}
+
+// journalKey converts a field key into a valid journal field name, which can only contain uppercase letters, digits,
+// and underscores, has to start with a letter, and is at most 64 characters long
+func journalKey(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9'):
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+
+	if name == "" || name[0] < 'A' || name[0] > 'Z' {
+		name = "FIELD_" + name
+	}
+
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
+
+// Write sends a log line to the journal
+func (j *journaldWriter) Write(logger *Logger, level *Level, line string, fields []Field) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	if j.closed {
+		return
+	}
+
+	_, err := j.conn.Write(j.message(logger, level, line, fields))
+	if err != nil {
+		diagnose("Failed to write log line:", err)
+	}
+}
+
+// Close closes the journal connection
+func (j *journaldWriter) Close() error {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	if j.closed {
+		return nil
+	}
+
+	j.closed = true
+	return j.conn.Close()
+}
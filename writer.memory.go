@@ -0,0 +1,50 @@
+package logpher
+
+import "sync"
+
+// MemoryWriter defines a writer that keeps formatted log lines in memory, for asserting on log output in tests
+type MemoryWriter struct {
+	lock   *sync.Mutex
+	lines  []string
+	format Formatter
+}
+
+// NewMemoryWriter creates a new memory writer using the supplied formatter, or the standard format when it's nil
+func NewMemoryWriter(format Formatter) *MemoryWriter {
+	if format == nil {
+		format = formatStandard
+	}
+
+	return &MemoryWriter{
+		lock:   &sync.Mutex{},
+		format: format,
+	}
+}
+
+// Write formats a log line and keeps it
+func (m *MemoryWriter) Write(logger *Logger, level *Level, line string, fields []Field) {
+	formatted := m.format(logger, level, line, fields)
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.lines = append(m.lines, formatted)
+}
+
+// Lines gets a copy of the lines written so far
+func (m *MemoryWriter) Lines() []string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return append([]string{}, m.lines...)
+}
+
+// Reset discards the lines written so far
+func (m *MemoryWriter) Reset() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.lines = nil
+}
+
+// Close does nothing, the lines stay available after the writer is closed
+func (m *MemoryWriter) Close() error {
+	return nil
+}
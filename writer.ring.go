@@ -0,0 +1,105 @@
+package logpher
+
+import (
+	"sync"
+	"time"
+)
+
+// RingWriter creates a writer that keeps the last size lines below the trigger level in memory, and only writes them
+// to the supplied writer ahead of a line at or above the trigger level. This gives the lead up to an error without
+// writing everything all the time
+func RingWriter(writer Writer, size int, trigger *Level) Writer {
+	return newRingWriter(writer, size, trigger)
+}
+
+// ringWriter defines a writer that holds recent lines in a circular buffer until a line at the trigger level arrives
+type ringWriter struct {
+	lock    *sync.Mutex
+	closed  bool
+	writer  Writer
+	trigger *Level
+	lines   []*lineEntry
+	next    int
+	count   int
+}
+
+// newRingWriter creates a new ring writer, holding at least one line
+func newRingWriter(writer Writer, size int, trigger *Level) *ringWriter {
+	if size < 1 {
+		size = 1
+	}
+
+	return &ringWriter{
+		lock:    &sync.Mutex{},
+		writer:  writer,
+		trigger: trigger,
+		lines:   make([]*lineEntry, size),
+	}
+}
+
+// Write holds a line below the trigger level, overwriting the oldest held line when the buffer is full. Held lines keep
+// the time they were logged at. Lines at or above the trigger level are written after all of the held lines
+func (r *ringWriter) Write(logger *Logger, level *Level, line string, fields []Field) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.closed {
+		return
+	}
+
+	if level.value < r.trigger.value {
+		r.lines[r.next] = &lineEntry{logger: logger.heldAt(time.Now()), level: level, line: line, fields: fields}
+		r.next = (r.next + 1) % len(r.lines)
+		if r.count < len(r.lines) {
+			r.count++
+		}
+		return
+	}
+
+	// Write the held lines oldest first, then the triggering line
+	start := (r.next - r.count + len(r.lines)) % len(r.lines)
+	for i := 0; i < r.count; i++ {
+		held := r.lines[(start+i)%len(r.lines)]
+		r.writer.Write(held.logger, held.level, held.line, held.fields)
+		r.lines[(start+i)%len(r.lines)] = nil
+	}
+	r.count = 0
+
+	r.writer.Write(logger, level, line, fields)
+}
+
+// Flush flushes the underlying writer. Held lines stay held, since they're only written ahead of a triggering line
+func (r *ringWriter) Flush() {
+	flush(r.writer)
+}
+
+// Rotate rotates the underlying writer
+func (r *ringWriter) Rotate() error {
+	return rotateWriter(r.writer)
+}
+
+// Reopen reopens the underlying writer
+func (r *ringWriter) Reopen() error {
+	return reopenWriter(r.writer)
+}
+
+// Sync syncs the underlying writer
+func (r *ringWriter) Sync() error {
+	return syncWriter(r.writer)
+}
+
+// Close discards the held lines and closes the underlying writer
+func (r *ringWriter) Close() error {
+	r.lock.Lock()
+	if r.closed {
+		r.lock.Unlock()
+		return nil
+	}
+
+	r.closed = true
+	r.lines = nil
+	r.count = 0
+	r.lock.Unlock()
+
+	return r.writer.Close()
+}
@@ -1,174 +1,1452 @@
 package logpher
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 )
 
-// rollingWriter defines a log writer that rotates files up to the maximum count
-type rollingWriter struct {
-	lock         *sync.Mutex
-	closed       bool
-	file         *os.File
-	fileName     string
-	maxSize      int64
-	maxCount     int
-	bytesWritten int64
+// RollingWriter defines a log writer that rotates files up to the maximum count
+type RollingWriter struct {
+	lock          *sync.Mutex
+	closed        bool
+	file          *os.File
+	buffer        *bufio.Writer
+	done          chan struct{}
+	fileName      string
+	pattern       string
+	family        *regexp.Regexp
+	maxSize       int64
+	maxLines      int64
+	maxCount      int
+	maxAge        time.Duration
+	maxTotal      int64
+	compress      bool
+	compressOld   bool
+	compressLevel int
+	compressor    Compressor
+	syncWrites    bool
+	syncEvery     int
+	unsynced      int
+	numbered      bool
+	localArchive  bool
+	latest        bool
+	archiveDir    string
+	interval      time.Duration
+	flushEvery    time.Duration
+	watch         time.Duration
+	backoff       time.Duration
+	freeSpace     bool
+	pausedUntil   time.Time
+	skipped       int
+	retries       int
+	retryDelay    time.Duration
+	keepFailed    bool
+	kept          []byte
+	keptLines     int
+	errorHandler  func(error)
+	onRotate      func(archivePath string)
+	metrics       Metrics
+	separator     string
+	header        string
+	marker        bool
+	marked        int64
+	lastLogger    *Logger
+	format        Formatter
+	standard      bool // Whether lines use the standard format, which is built straight into the write buffer
+	now           func() time.Time
+	openedAt      time.Time
+	bytesWritten  int64
+	linesWritten  int64
+	rotations     int64
 }
 
-// newRollingWriter creates a new rolling writer
-func newRollingWriter(fileName string, maxSize int, maxCount int) *rollingWriter {
-	writer := &rollingWriter{
-		lock:         &sync.Mutex{},
-		file:         nil,
-		fileName:     toAbsolutePath(fileName),
-		maxSize:      int64(maxSize * megabyte),
-		maxCount:     maxCount,
-		bytesWritten: 0,
+// Rolling writer defaults
+const (
+	defaultRollingSize  = 100 * mebibyte
+	defaultRollingCount = 5
+	defaultSeparator    = "\n"
+)
+
+// latestExt defines the extension of the link to the most recently rotated file
+const latestExt = ".latest"
+
+// errNoFile defines the error for writing when the live file couldn't be opened
+var errNoFile = errors.New("log file isn't open")
+
+// errPaused defines the error for batches written while writes are backed off after the disk filled up
+var errPaused = errors.New("log writes are paused after the disk filled up")
+
+// maxKept defines the most failed line data kept for the next write, so a file that can't be written doesn't grow it
+// without limit
+const maxKept = mebibyte
+
+// nextExt defines the extension the new live file is created with during rotation, before it's moved into place
+const nextExt = ".next"
+
+// markerPrefix defines the start of the rotation marker message
+const markerPrefix = "--- log opened "
+
+// maxMarker defines how much of an existing live file is read looking for the rotation marker
+const maxMarker = 4 * kibibyte
+
+// datePlaceholders defines the file name placeholders that are expanded with the current date, and the patterns that
+// match their expansions
+var datePlaceholders = []struct {
+	placeholder string
+	layout      string
+	pattern     string
+}{
+	{"%Y", "2006", `\d{4}`},
+	{"%m", "01", `\d{2}`},
+	{"%d", "02", `\d{2}`},
+	{"%H", "15", `\d{2}`},
+}
+
+// archive defines a rotated log file. Family archives are files of the same dated file name family that don't belong
+// to the live file, such as the live files (which have no rotation suffix) and archives of previous days
+type archive struct {
+	path      string
+	timestamp time.Time
+	index     int
+	size      int64
+	family    bool
+	live      bool
+}
+
+// newRollingWriter creates a new rolling writer with a maximum size in binary megabytes (MiB), panicking if it can't be
+// created
+func newRollingWriter(fileName string, maxSize int, maxCount int) *RollingWriter {
+	writer, err := NewRollingWriter(fileName, WithMaxSize(int64(maxSize)*mebibyte), WithMaxCount(maxCount))
+	panicOnError(err)
+	return writer
+}
+
+// NewRollingWriter creates a new rolling writer for the supplied file, returning an error if the live file can't be
+// set up. Without options, files are rotated at 100MB, 5 rotated files are kept, and lines use the standard format.
+// The file name can contain %Y, %m, %d, and %H date placeholders, in which case the live file moves to the newly
+// expanded name whenever the date changes
+func NewRollingWriter(fileName string, opts ...Option) (*RollingWriter, error) {
+	writer := &RollingWriter{
+		lock:          &sync.Mutex{},
+		done:          make(chan struct{}),
+		maxSize:       defaultRollingSize,
+		maxCount:      defaultRollingCount,
+		metrics:       noMetrics{},
+		separator:     defaultSeparator,
+		format:        formatStandard,
+		standard:      true,
+		now:           time.Now,
+		compressLevel: gzip.DefaultCompression,
+	}
+
+	for _, opt := range opts {
+		opt(writer)
+	}
+	return writer.open(fileName)
+}
+
+// open resolves the configured paths and opens the live file, rotating it if it's already due
+func (r *RollingWriter) open(fileName string) (*RollingWriter, error) {
+
+	// Make sure the limits won't rotate on every line or delete files that should be kept
+	if r.maxSize <= 0 {
+		return nil, fmt.Errorf("rolling file size must be positive: %d", r.maxSize)
+	}
+	if r.maxCount < 0 {
+		return nil, fmt.Errorf("rolling file count can't be negative: %d", r.maxCount)
+	}
+	if r.maxLines < 0 || r.maxTotal < 0 || r.maxAge < 0 {
+		return nil, errors.New("rolling file line, total size, and age limits can't be negative")
+	}
+
+	// Compress with gzip unless another format was supplied, making sure the level is valid before anything is rotated
+	if r.compressor == nil {
+		if r.compressLevel < gzip.HuffmanOnly || r.compressLevel > gzip.BestCompression {
+			return nil, fmt.Errorf("invalid gzip compression level: %d", r.compressLevel)
+		}
+		r.compressor = gzipCompressor{level: r.compressLevel}
+	}
+
+	// Resolve the file path
+	absolutePath, err := filepath.Abs(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resolve the archive directory relative to the log directory, creating it if needed
+	archiveDir := r.archiveDir
+	if filepath.IsAbs(archiveDir) {
+		archiveDir = filepath.Clean(archiveDir)
+	} else {
+		archiveDir = filepath.Join(filepath.Dir(absolutePath), archiveDir)
+	}
+
+	err = os.MkdirAll(archiveDir, 0755)
+	if err != nil {
+		return nil, err
 	}
 
+	r.pattern = absolutePath
+	r.family = familyPattern(filepath.Base(absolutePath))
+	r.fileName = r.expandName(r.now())
+	r.archiveDir = archiveDir
+	r.openedAt = r.now()
+
 	// Check if there's already a live log file
-	info, err := os.Stat(writer.fileName)
+	err = notDirectory(r.fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(r.fileName)
 	if err != nil {
 
 		// Make sure it's a file doesn't exist error
 		if !os.IsNotExist(err) {
-			panic(err)
+			return nil, err
 		}
 
 		// Create the live file
-		writer.file, err = openFile(writer.fileName)
-		panicOnError(err)
+		err = r.openLive()
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = r.closeOnError(r.writeHeader())
+		if err != nil {
+			return nil, err
+		}
 
 		// Delete old files
-		panicOnError(writer.deleteOld())
-		return writer
+		return r.start(r.cleanUp())
 	}
 
 	// The file already exists, open it up
-	writer.file, err = openFile(writer.fileName)
-	panicOnError(err)
+	err = r.openLive()
+	if err != nil {
+		return nil, err
+	}
 
-	// Store the size of it and rotate if necessary
-	writer.bytesWritten = info.Size()
-	if writer.bytesWritten >= writer.maxSize {
-		panicOnError(writer.rotate())
+	// Store the size and age of it and rotate if necessary
+	r.bytesWritten = info.Size()
+	r.openedAt = info.ModTime()
+	_, err = r.closeOnError(r.resume())
+	if err != nil {
+		return nil, err
+	}
+	if r.full() || (!r.empty() && r.intervalElapsed()) {
+		_, err = r.closeOnError(r.rotate())
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// Delete old files
-	panicOnError(writer.deleteOld())
-	return writer
+	return r.start(r.cleanUp())
+}
+
+// cleanUp compresses any rotated files left uncompressed when that's enabled, and deletes old files
+func (r *RollingWriter) cleanUp() error {
+	if r.compress && r.compressOld {
+		err := r.compressExisting()
+		if err != nil {
+			return err
+		}
+	}
+	return r.deleteOld()
+}
+
+// compressExisting compresses the rotated files that aren't compressed yet, such as files rotated before compression
+// was enabled or left behind by a crash during rotation. Previous live files of a dated family are left as they are
+func (r *RollingWriter) compressExisting() error {
+	logFiles, err := r.archives()
+	if err != nil {
+		return err
+	}
+
+	for _, logFile := range logFiles {
+		if logFile.live || compressedExt(logFile.path, r.compressor) != "" {
+			continue
+		}
+
+		err = compressFile(logFile.path, r.compressor)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// closeOnError closes the live file when a non-nil error is supplied, so a failed construction doesn't leak it
+func (r *RollingWriter) closeOnError(err error) (*RollingWriter, error) {
+	if err != nil {
+		if r.file != nil {
+			_ = r.file.Close()
+		}
+		return nil, err
+	}
+	return r, nil
+}
+
+// start finishes constructing the writer, starting the periodic flush when buffering is enabled
+func (r *RollingWriter) start(err error) (*RollingWriter, error) {
+	_, err = r.closeOnError(err)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.buffer != nil {
+		go r.flushPeriodically()
+	}
+
+	if r.watch > 0 {
+		go r.watchPeriodically()
+	}
+	return r, nil
+}
+
+// familyPattern creates a pattern matching the expansions of a file name containing date placeholders, followed by an
+// optional suffix. It returns nil if the file name doesn't contain any placeholders
+func familyPattern(name string) *regexp.Regexp {
+	pattern := regexp.QuoteMeta(name)
+	for _, date := range datePlaceholders {
+		pattern = strings.ReplaceAll(pattern, date.placeholder, date.pattern)
+	}
+
+	if pattern == regexp.QuoteMeta(name) {
+		return nil
+	}
+	return regexp.MustCompile(`^(` + pattern + `)(\..+)?$`)
+}
+
+// expandName expands the date placeholders in the file name with the supplied time
+func (r *RollingWriter) expandName(now time.Time) string {
+	if r.family == nil {
+		return r.pattern
+	}
+
+	name := filepath.Base(r.pattern)
+	for _, date := range datePlaceholders {
+		name = strings.ReplaceAll(name, date.placeholder, now.Format(date.layout))
+	}
+	return filepath.Join(filepath.Dir(r.pattern), name)
 }
 
-// rotate renames the current live file and creates a new one
-func (r *rollingWriter) rotate() error {
+// switchFile closes the live file and opens the supplied one in its place, continuing from its size if it already
+// exists. The previous file is left where it is as part of the dated file name family
+func (r *RollingWriter) switchFile(fileName string) error {
 
-	// Close the open file
-	err := r.file.Close()
+	// Open the new file first, so the current one stays live if it can't be opened
+	file, err := r.openPath(fileName, 0)
 	if err != nil {
 		return err
 	}
 
-	// Rename it
-	err = os.Rename(r.fileName, r.fileName+"."+time.Now().Format(time.RFC3339))
+	info, err := file.Stat()
+	if err != nil {
+		return errors.Join(err, file.Close())
+	}
+
+	// Flush, sync, and close the current file, moving on to the new one even if that fails
+	err = r.closeLive()
+	r.fileName = fileName
+	r.useFile(file)
+	r.bytesWritten = info.Size()
+	r.openedAt = r.now()
+	return errors.Join(err, r.resume())
+}
+
+// resume prepares to append to an existing live file, terminating any partial last line, starting it with the header
+// if it's empty, and counting the lines in it
+func (r *RollingWriter) resume() error {
+	r.marked = 0
+	err := r.terminateLine()
 	if err != nil {
 		return err
 	}
 
-	// Create a new "live" file
-	r.bytesWritten = 0
-	r.file, err = openFile(r.fileName)
-	return err
+	err = r.writeHeader()
+	if err != nil {
+		return err
+	}
+
+	err = r.findMarker()
+	if err != nil {
+		return err
+	}
+	return r.countLines()
 }
 
-// deleteOld deletes old log files, based on the configured max count
-func (r *rollingWriter) deleteOld() error {
+// writeHeader writes the header to the live file if there is one and nothing has been written to the file yet. It's
+// counted towards the size, but not the line count
+func (r *RollingWriter) writeHeader() error {
+	if r.header == "" || r.bytesWritten > 0 {
+		return nil
+	}
+
+	count, err := r.write([]byte(r.header + r.separator))
+	r.bytesWritten += int64(count)
+	if err != nil {
+		return err
+	}
+	return r.flushBuffer()
+}
 
-	// Get the log directory
-	directory := filepath.Dir(r.fileName)
+// writeMarker writes a line at the start of a new live file recording where the previous one was rotated to. It's
+// formatted for the logger that last wrote a line, so it looks like the rest of the file, and it's counted towards the
+// size, but not the line count
+func (r *RollingWriter) writeMarker(archive string) error {
+	message := fmt.Sprintf("%s%s, previous file rotated to %s ---", markerPrefix, r.openedAt.Format(time.RFC3339), archive)
+	count, err := r.write([]byte(r.format(r.lastLogger, Info, message, nil) + r.separator))
+	r.bytesWritten += int64(count)
+	r.marked = int64(count)
+	return err
+}
 
-	// Walk the directory we're logging to and find the log files
-	var logFiles []string
-	err := filepath.Walk(directory, func(path string, info os.FileInfo, err error) error {
+// findMarker measures the rotation marker at the start of an existing live file, so a restart doesn't count it as a
+// line
+func (r *RollingWriter) findMarker() error {
+	r.marked = 0
+	if !r.marker || r.separator == "" || r.bytesWritten == 0 {
+		return nil
+	}
 
-		// Not a matching file
-		if !strings.HasPrefix(path, r.fileName) {
-			return nil
-		}
+	file, err := os.Open(r.fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
 
-		// Matching file, check if it has a timestamp on the end
-		split := strings.Split(path, ".")
-		_, err = time.Parse(time.RFC3339, split[len(split)-1])
+	// The marker is the first line after the header
+	preamble := make([]byte, maxMarker)
+	if r.header != "" {
+		_, err = file.Seek(int64(len(r.header)+len(r.separator)), io.SeekStart)
 		if err != nil {
-			return nil
+			return err
 		}
+	}
+
+	count, err := io.ReadFull(file, preamble)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return err
+	}
+
+	line := preamble[:count]
+	end := bytes.Index(line, []byte(r.separator))
+	if end >= 0 && bytes.Contains(line[:end], []byte(markerPrefix)) {
+		r.marked = int64(end + len(r.separator))
+	}
+	return nil
+}
+
+// empty determines if no lines have been written to the live file, which can still contain the header and the
+// rotation marker
+func (r *RollingWriter) empty() bool {
+	preamble := r.marked
+	if r.header != "" {
+		preamble += int64(len(r.header) + len(r.separator))
+	}
+	return r.bytesWritten <= preamble
+}
 
-		logFiles = append(logFiles, path)
+// countLines counts the separators in the live file when rotating by line count, so a restart carries on counting
+// from where the file left off
+func (r *RollingWriter) countLines() error {
+	r.linesWritten = 0
+	if r.maxLines <= 0 || r.separator == "" || r.bytesWritten == 0 {
 		return nil
-	})
+	}
 
-	// If there was a walk error, return that
+	file, err := os.Open(r.fileName)
 	if err != nil {
 		return err
 	}
+	defer file.Close()
 
-	// Delete files until we're at the max count
-	for len(logFiles) > r.maxCount {
+	// Read in chunks, carrying the end of each chunk over so separators spanning two chunks are still counted
+	separator := []byte(r.separator)
+	chunk := make([]byte, 64*1024)
+	var carry []byte
+	for {
+		count, err := file.Read(chunk)
+		data := append(carry, chunk[:count]...)
+		r.linesWritten += int64(bytes.Count(data, separator))
 
-		// Pop the first path
-		path := logFiles[0]
-		logFiles = logFiles[1:]
+		// Only the bytes after the last separator can be the start of another one
+		start := len(data) - len(separator) + 1
+		if last := bytes.LastIndex(data, separator); last >= 0 && last+len(separator) > start {
+			start = last + len(separator)
+		}
+		if start < 0 {
+			start = 0
+		}
+		carry = append([]byte(nil), data[start:]...)
 
-		// Delete the file
-		err := os.Remove(path)
+		if err == io.EOF {
+			break
+		}
 		if err != nil {
 			return err
 		}
 	}
 
+	// Neither the header nor the rotation marker is a line
+	if r.header != "" && r.linesWritten > 0 {
+		r.linesWritten--
+	}
+	if r.marked > 0 && r.linesWritten > 0 {
+		r.linesWritten--
+	}
 	return nil
 }
 
-// write writes a log line to the file
-func (r *rollingWriter) write(logger *Logger, level *level, line string) {
-	r.lock.Lock()
-	defer r.lock.Unlock()
+// full determines if the live file has reached the maximum size or line count
+func (r *RollingWriter) full() bool {
+	return r.bytesWritten >= r.maxSize || (r.maxLines > 0 && r.linesWritten >= r.maxLines)
+}
 
-	if r.closed {
-		return
+// terminateLine writes a separator to the live file if it doesn't end with one, so lines appended after a crash left a
+// partial line behind don't run into it
+func (r *RollingWriter) terminateLine() error {
+	size := int64(len(r.separator))
+	if size == 0 || r.bytesWritten == 0 {
+		return nil
 	}
 
-	count, err := r.file.WriteString(formatStandard(logger, level, line) + "\n")
+	// The live file is opened for appending only, so read the end of it separately
+	file, err := os.Open(r.fileName)
 	if err != nil {
-		fmt.Println("Failed to write log line:", err)
-		return
+		return err
+	}
+	defer file.Close()
+
+	// A file shorter than the separator can't end with one
+	ending := make([]byte, size)
+	if r.bytesWritten >= size {
+		_, err = file.ReadAt(ending, r.bytesWritten-size)
+		if err != nil {
+			return err
+		}
+		if string(ending) == r.separator {
+			return nil
+		}
 	}
 
-	// Rotate if we've written more than we're allowed in the file
+	count, err := r.write([]byte(r.separator))
 	r.bytesWritten += int64(count)
-	if r.bytesWritten >= r.maxSize {
+	if err != nil {
+		return err
+	}
+	return r.flushBuffer()
+}
 
-		err := r.rotate()
-		if err != nil {
-			fmt.Println("Failed to rotate log file:", err)
+// openLive opens the live file, wrapping it in a buffer when buffering is enabled
+func (r *RollingWriter) openLive() error {
+	file, err := r.openPath(r.fileName, 0)
+	if err != nil {
+		return err
+	}
+
+	r.useFile(file)
+	return nil
+}
+
+// openPath opens a file for appending with the configured flags, plus the supplied ones
+func (r *RollingWriter) openPath(path string, flags int) (*os.File, error) {
+	if r.syncWrites {
+		flags |= os.O_SYNC
+	}
+	return openFile(path, flags)
+}
+
+// useFile makes the supplied file the live file, wrapping it in a buffer when buffering is enabled
+func (r *RollingWriter) useFile(file *os.File) {
+	r.file = file
+	r.buffer = nil
+	if r.flushEvery > 0 {
+		r.buffer = bufio.NewWriter(file)
+	}
+}
+
+// closeLive flushes, syncs, and closes the live file, leaving the writer without one
+func (r *RollingWriter) closeLive() error {
+	if r.file == nil {
+		return nil
+	}
+
+	err := errors.Join(r.flushBuffer(), r.file.Sync(), r.file.Close())
+	r.file = nil
+	r.buffer = nil
+	return err
+}
+
+// syncFile commits the live file to stable storage
+func (r *RollingWriter) syncFile() error {
+	if r.file == nil {
+		return errNoFile
+	}
+	return r.file.Sync()
+}
+
+// write writes data to the live file, going through the buffer when buffering is enabled. It fails without a live
+// file, so the line is reported to the error handler until the file is reopened
+func (r *RollingWriter) write(data []byte) (int, error) {
+	if r.file == nil {
+		return 0, errNoFile
+	}
+	if r.buffer != nil {
+		return r.buffer.Write(data)
+	}
+	return r.file.Write(data)
+}
+
+// writeRetrying writes data to the live file, retrying failed writes with a doubling delay up to the configured number
+// of retries. Retries continue from where a partial write stopped, and hold up other writes while they wait
+func (r *RollingWriter) writeRetrying(data []byte) (int, error) {
+	written := 0
+	delay := r.retryDelay
+	for attempt := 0; ; attempt++ {
+		count, err := r.write(data[written:])
+		if err != nil && r.buffer != nil {
+
+			// A buffer that failed won't accept more writes, and what it held is lost
+			r.buffer.Reset(r.file)
+			count = 0
 		}
 
-		err = r.deleteOld()
-		if err != nil {
-			fmt.Println("Failed to delete old log file:", err)
+		written += count
+		if err == nil || attempt >= r.retries || errors.Is(err, errNoFile) {
+			return written, err
+		}
+
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// keep holds line data that failed to write so it's written ahead of the next line, when that's enabled. Lines that
+// would take the held data over the limit are dropped
+func (r *RollingWriter) keep(data []byte, lines int) {
+	if !r.keepFailed {
+		return
+	}
+
+	if len(r.kept)+len(data) > maxKept {
+		r.handleError(fmt.Errorf("dropped %d log lines that failed to write", lines))
+		return
+	}
+
+	r.kept = append(r.kept, data...)
+	r.keptLines += lines
+}
+
+// flushBuffer flushes any buffered data to the live file
+func (r *RollingWriter) flushBuffer() error {
+	if r.buffer == nil {
+		return nil
+	}
+	return r.buffer.Flush()
+}
+
+// flushPeriodically flushes the buffer at the configured interval until the writer is closed
+func (r *RollingWriter) flushPeriodically() {
+	ticker := time.NewTicker(r.flushEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.Flush()
+		case <-r.done:
+			return
+		}
+	}
+}
+
+// watchPeriodically checks the live file at the configured interval until the writer is closed
+func (r *RollingWriter) watchPeriodically() {
+	ticker := time.NewTicker(r.watch)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.checkLive()
+		case <-r.done:
+			return
 		}
 	}
 }
 
-// close closes the writer
-func (r *rollingWriter) close() {
+// checkLive reopens the live file if it was deleted or replaced by another file, since lines written to the open file
+// would otherwise be lost
+func (r *RollingWriter) checkLive() {
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
-	_ = r.file.Close()
+	if r.closed {
+		return
+	}
+
+	// The open file is still the one with the live file's name. Without an open file, it's always reopened
+	named, err := os.Stat(r.fileName)
+	if err == nil && r.file != nil {
+		open, err := r.file.Stat()
+		if err != nil || os.SameFile(named, open) {
+			return
+		}
+	} else if err != nil && !os.IsNotExist(err) {
+		return
+	}
+
+	err = r.reopen()
+	if err != nil {
+		r.handleError(fmt.Errorf("failed to reopen log file: %w", err))
+	}
+}
+
+// rotate renames the current live file and creates a new one. The new file is created before anything is renamed, and
+// the open file is only replaced once the new one is in place, so a failure leaves the writer on the open file
+func (r *RollingWriter) rotate() error {
+	if r.file == nil {
+		return errNoFile
+	}
+
+	// Flush anything buffered for the open file and sync it
+	err := r.flushBuffer()
+	if err != nil {
+		return err
+	}
+
+	err = r.file.Sync()
+	if err != nil {
+		return err
+	}
+
+	// Create the new live file under a temporary name, keeping the mode and owner of the open file
+	original, err := r.file.Stat()
+	if err != nil {
+		return err
+	}
+
+	next, err := r.openPath(r.fileName+nextExt, os.O_TRUNC)
+	if err != nil {
+		return err
+	}
+
+	err = preserveAttributes(next, original)
+	if err != nil {
+		return discardFile(next, err)
+	}
+
+	// Rename the open file and move the new one into place
+	archive, err := r.archivePath()
+	if err != nil {
+		return discardFile(next, err)
+	}
+
+	next, err = r.swapFiles(next, archive)
+	if err != nil {
+		return err
+	}
+
+	r.useFile(next)
+	r.rotations++
+	r.metrics.Rotated()
+	r.bytesWritten = 0
+	r.linesWritten = 0
+	r.unsynced = 0
+	r.marked = 0
+	r.openedAt = r.now()
+
+	err = r.writeHeader()
+	if err != nil {
+		return err
+	}
+
+	// Start the new file with a marker pointing at the rotated one, once a logger has written a line to format it for
+	if r.marker && r.lastLogger != nil {
+		rotated := archive
+		if r.compress {
+			rotated += r.compressor.Extension()
+		}
+
+		err = r.writeMarker(rotated)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Compress the archive if required
+	if r.compress {
+		err = compressFile(archive, r.compressor)
+		if err != nil {
+			return err
+		}
+		archive += r.compressor.Extension()
+	}
+
+	// Point the latest link at the new archive if required
+	if r.latest {
+		err = r.linkLatest(archive)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Notify the rotation hook on its own goroutine, so a slow hook doesn't hold up writes
+	if r.onRotate != nil {
+		go r.onRotate(archive)
+	}
+
+	return nil
+}
+
+// linkLatest atomically replaces the latest link with one pointing at the supplied archive. Where symlinks can't be
+// created (e.g. on Windows without the required privileges), a pointer file containing the archive path is written
+// instead
+func (r *RollingWriter) linkLatest(archive string) error {
+	link := r.fileName + latestExt
+	temporary := link + ".tmp"
+	_ = os.Remove(temporary)
+
+	// Link relative to the log directory, so the link survives the directory being moved
+	target, err := filepath.Rel(filepath.Dir(link), archive)
+	if err != nil {
+		target = archive
+	}
+
+	err = os.Symlink(target, temporary)
+	if err != nil {
+		err = os.WriteFile(temporary, []byte(archive+"\n"), 0644)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Renaming over the old link is atomic, so readers always see a valid link
+	return os.Rename(temporary, link)
+}
+
+// archivePrefix determines the path rotated file suffixes are appended to, the live file name in the archive directory
+func (r *RollingWriter) archivePrefix() string {
+	return filepath.Join(r.archiveDir, filepath.Base(r.fileName)) + "."
+}
+
+// archivePath determines the path to rotate the live file to. Numbered archives are shifted up to make room for a new
+// first archive. For timestamped archives, if an archive with the current timestamp already exists the timestamp is
+// moved forward until it's unique, so an existing archive is never overwritten
+func (r *RollingWriter) archivePath() (string, error) {
+	if r.numbered {
+		return r.archivePrefix() + "1", r.shiftNumbered()
+	}
+
+	// Suffixes are in UTC unless local time is requested, so names don't shift with the timezone or daylight saving
+	timestamp := r.now()
+	if !r.localArchive {
+		timestamp = timestamp.UTC()
+	}
+
+	for {
+		path := r.archivePrefix() + timestamp.Format(archiveLayout)
+		if !r.archiveExists(path) {
+			return path, nil
+		}
+		timestamp = timestamp.Add(time.Nanosecond)
+	}
+}
+
+// archiveExists determines if an archive exists at the supplied path, compressed or not
+func (r *RollingWriter) archiveExists(path string) bool {
+	if exists(path) || exists(path+r.compressor.Extension()) {
+		return true
+	}
+
+	for _, extension := range compressedExts {
+		if exists(path + extension) {
+			return true
+		}
+	}
+	return false
+}
+
+// shiftNumbered renames each numbered archive to the next number, starting with the oldest so nothing is overwritten
+func (r *RollingWriter) shiftNumbered() error {
+	logFiles, err := r.archives()
+	if err != nil {
+		return err
+	}
+
+	for _, logFile := range logFiles {
+		if logFile.family {
+			continue
+		}
+
+		extension := compressedExt(logFile.path, r.compressor)
+		err = os.Rename(logFile.path, r.archivePrefix()+strconv.Itoa(logFile.index+1)+extension)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// intervalElapsed determines if the live file was opened before the current rotation interval started
+func (r *RollingWriter) intervalElapsed() bool {
+	if r.interval <= 0 {
+		return false
+	}
+	return !r.now().Truncate(r.interval).Equal(r.openedAt.Truncate(r.interval))
+}
+
+// rollOver rotates the live file and deletes old files, printing any errors
+func (r *RollingWriter) rollOver() {
+	err := r.rotate()
+	if err != nil {
+		r.handleError(fmt.Errorf("failed to rotate log file: %w", err))
+	}
+
+	err = r.deleteOld()
+	if err != nil {
+		r.handleError(fmt.Errorf("failed to delete old log file: %w", err))
+	}
+}
+
+// switchOver moves to the supplied file and deletes old files, printing any errors
+func (r *RollingWriter) switchOver(fileName string) {
+	err := r.switchFile(fileName)
+	if err != nil {
+		r.handleError(fmt.Errorf("failed to switch log file: %w", err))
+	}
+
+	err = r.deleteOld()
+	if err != nil {
+		r.handleError(fmt.Errorf("failed to delete old log file: %w", err))
+	}
+}
+
+// backOff stops writes for the backoff window when an error was caused by a full disk, so every line doesn't fail and
+// report an error while there's no space. Old files are deleted straight away to free space when that's enabled
+func (r *RollingWriter) backOff(err error) {
+	if r.backoff <= 0 || !errors.Is(err, syscall.ENOSPC) {
+		return
+	}
+
+	r.pausedUntil = r.now().Add(r.backoff)
+	if r.freeSpace {
+		err = r.deleteOld()
+		if err != nil {
+			r.handleError(fmt.Errorf("failed to delete old log file: %w", err))
+		}
+	}
+}
+
+// paused determines if writes are backed off, resuming them once the backoff window has passed. The buffer keeps
+// failing after an error, so it's reset when writes resume, and the number of skipped lines is reported
+func (r *RollingWriter) paused() bool {
+	if r.pausedUntil.IsZero() {
+		return false
+	}
+
+	if r.now().Before(r.pausedUntil) {
+		return true
+	}
+
+	r.pausedUntil = time.Time{}
+	if r.buffer != nil {
+		r.buffer.Reset(r.file)
+	}
+
+	if r.skipped > 0 {
+		r.handleError(fmt.Errorf("skipped %d log lines while the disk was full", r.skipped))
+		r.skipped = 0
+	}
+	return false
+}
+
+// handleError passes an internal failure to the error handler, falling back to printing it when there isn't one
+func (r *RollingWriter) handleError(err error) {
+	r.metrics.Failed(err)
+	if r.errorHandler != nil {
+		r.errorHandler(err)
+		return
+	}
+	defaultErrorHandler(err)
+}
+
+// parseArchive parses a path into a rotated log file. Only paths consisting of the live file name, a timestamp (or a
+// number when numbering archives), and an optional compression extension are considered rotated files
+func (r *RollingWriter) parseArchive(path string) (*archive, bool) {
+
+	// Archives of the live file
+	logFile, ok := r.parseSuffix(path, r.archivePrefix())
+	if ok || r.family == nil || path == r.fileName {
+		return logFile, ok
+	}
+
+	// Other files in the dated file name family, which are either previous live files or their archives
+	match := r.family.FindStringSubmatch(filepath.Base(path))
+	if match == nil {
+		return nil, false
+	}
+
+	if match[2] == "" {
+		return &archive{path: path, family: true, live: true}, true
+	}
+
+	logFile, ok = r.parseSuffix(path, strings.TrimSuffix(path, match[2])+".")
+	if ok {
+		logFile.family = true
+	}
+	return logFile, ok
+}
+
+// parseSuffix parses a rotated file path made up of the supplied prefix followed by a suffix
+func (r *RollingWriter) parseSuffix(path string, prefix string) (*archive, bool) {
+
+	// Make sure the path is the prefix followed by a suffix
+	if !strings.HasPrefix(path, prefix) {
+		return nil, false
+	}
+
+	// Ignore the compression extension
+	suffix := strings.TrimPrefix(path, prefix)
+	suffix = strings.TrimSuffix(suffix, compressedExt(suffix, r.compressor))
+
+	// Numbered suffixes have to be a positive integer without leading zeros
+	if r.numbered {
+		index, err := strconv.Atoi(suffix)
+		if err != nil || index < 1 || strconv.Itoa(index) != suffix {
+			return nil, false
+		}
+		return &archive{path: path, index: index}, true
+	}
+
+	// Otherwise the suffix has to be a timestamp
+	timestamp, err := parseArchiveTime(suffix)
+	if err != nil {
+		return nil, false
+	}
+
+	return &archive{path: path, timestamp: timestamp}, true
+}
+
+// exceedsCount determines if there are more rotated files than the max count. A zero count only disables the limit
+// when age or total size based retention is configured, otherwise it keeps no rotated files at all
+func (r *RollingWriter) exceedsCount(count int) bool {
+	if r.maxCount <= 0 && (r.maxAge > 0 || r.maxTotal > 0) {
+		return false
+	}
+	return count > r.maxCount
+}
+
+// exceedsTotal determines if the rotated files take up more than the max total size, when it's configured
+func (r *RollingWriter) exceedsTotal(total int64) bool {
+	return r.maxTotal > 0 && total > r.maxTotal
+}
+
+// expired determines if a rotated file is older than the cutoff, when age based retention is configured
+func (r *RollingWriter) expired(logFile *archive, cutoff time.Time) bool {
+	return r.maxAge > 0 && logFile.timestamp.Before(cutoff)
+}
+
+// archives finds the rotated log files, ordered from oldest to newest
+func (r *RollingWriter) archives() ([]*archive, error) {
+
+	// Previous files of a dated file name family stay in the log directory, so that's searched too
+	dirs := []string{r.archiveDir}
+	if logDir := filepath.Dir(r.fileName); r.family != nil && logDir != r.archiveDir {
+		dirs = append(dirs, logDir)
+	}
+
+	// List the directories without recursing into subdirectories, matching the entries by name
+	var logFiles []*archive
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+
+			// Not a rotated log file
+			logFile, ok := r.parseArchive(filepath.Join(dir, entry.Name()))
+			if !ok || entry.IsDir() {
+				continue
+			}
+
+			// Only matching entries are stat'd. Skip any that were deleted since the directory was listed
+			info, err := entry.Info()
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			// Numbered and family files don't carry a usable timestamp, so their age comes from the last modification
+			logFile.size = info.Size()
+			if r.numbered || logFile.family {
+				logFile.timestamp = info.ModTime()
+			}
+
+			logFiles = append(logFiles, logFile)
+		}
+	}
+
+	// Sort the files so the oldest ones come first. Higher numbers are older, and timestamps are compared by parsing
+	// them so the order is correct regardless of the timezone offset. Dated families mix in previous files, so they
+	// are always ordered by time
+	sort.SliceStable(logFiles, func(i, j int) bool {
+		if r.numbered && r.family == nil {
+			return logFiles[i].index > logFiles[j].index
+		}
+		return logFiles[i].timestamp.Before(logFiles[j].timestamp)
+	})
+
+	return logFiles, nil
+}
+
+// deleteOld deletes old log files, based on the configured max count, age, and total size
+func (r *RollingWriter) deleteOld() error {
+
+	// Find the rotated files
+	logFiles, err := r.archives()
+	if err != nil {
+		return err
+	}
+
+	// Total up the size of the files
+	var total int64
+	for _, logFile := range logFiles {
+		total += logFile.size
+	}
+
+	// Delete the oldest files while any of the configured limits are exceeded
+	cutoff := r.now().Add(-r.maxAge)
+	for len(logFiles) > 0 && (r.exceedsCount(len(logFiles)) || r.expired(logFiles[0], cutoff) || r.exceedsTotal(total)) {
+
+		// Pop the oldest file
+		oldest := logFiles[0]
+		logFiles = logFiles[1:]
+		total -= oldest.size
+
+		// Delete the file
+		err := os.Remove(oldest.path)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Write writes a log line to the file
+func (r *RollingWriter) Write(logger *Logger, level *Level, line string, fields []Field) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.closed {
+		return
+	}
+
+	// Skip the line while writes are backed off after the disk filled up
+	if r.paused() {
+		r.skipped++
+		return
+	}
+	r.rollIfDue()
+
+	// Build the line and separator in a pooled buffer, so they go out in one write without being concatenated. Lines
+	// kept from failed writes go out first
+	r.lastLogger = logger
+	buffer := getBuffer()
+	defer putBuffer(buffer)
+
+	buffer.Write(r.kept)
+	if r.standard {
+		appendText(buffer, logger, level.display, line, fields)
+	} else {
+		buffer.WriteString(r.format(logger, level, line, fields))
+	}
+	buffer.WriteString(r.separator)
+	lines := r.keptLines + 1
+	r.kept, r.keptLines = r.kept[:0], 0
+
+	err := r.writeLines(buffer.Bytes(), lines)
+	if err != nil {
+		r.handleError(fmt.Errorf("failed to write log line: %w", err))
+		r.backOff(err)
+		return
+	}
+
+	// Rotate if we've written more bytes or lines than we're allowed in the file
+	if r.full() {
+		r.rollOver()
+	}
+}
+
+// WriteBatch writes pre-formatted lines, such as lines being imported from another log, taking the lock once and
+// writing as many lines at a time as fit in the live file before it needs rotating. The separator is written after
+// each line. If a write fails, or writes are backed off after the disk filled up, the error is returned and the
+// remaining lines aren't written
+func (r *RollingWriter) WriteBatch(lines []string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.closed {
+		return errClosed
+	}
+
+	if r.paused() {
+		return errPaused
+	}
+
+	// Nothing to write, leave any kept lines for the next write
+	if len(lines) == 0 {
+		return nil
+	}
+	r.rollIfDue()
+
+	buffer := getBuffer()
+	defer putBuffer(buffer)
+
+	buffer.Write(r.kept)
+	pending := r.keptLines
+	r.kept, r.keptLines = r.kept[:0], 0
+
+	for i, line := range lines {
+		buffer.WriteString(line)
+		buffer.WriteString(r.separator)
+		pending++
+
+		// Keep building up lines until they'd fill the live file, then write them and rotate
+		if i < len(lines)-1 && !r.wouldFill(int64(buffer.Len()), int64(pending)) {
+			continue
+		}
+
+		err := r.writeLines(buffer.Bytes(), pending)
+		if err != nil {
+			r.metrics.Failed(err)
+			r.backOff(err)
+			return fmt.Errorf("failed to write log lines: %w", err)
+		}
+		buffer.Reset()
+		pending = 0
+
+		if r.full() {
+			r.rollOver()
+		}
+	}
+	return nil
+}
+
+// rollIfDue moves to a new file if the date in the file name has changed, and rotates the live file if it belongs to
+// a previous interval
+func (r *RollingWriter) rollIfDue() {
+
+	// Move to a new file if the date in the file name has changed, which also starts a new interval
+	if name := r.expandName(r.now()); name != r.fileName {
+		r.switchOver(name)
+	}
+
+	// Rotate if the live file belongs to a previous interval. Empty files are carried over into the current interval
+	// instead, so a size based rotation followed by a time based one never produces an empty archive
+	if r.intervalElapsed() {
+		if r.empty() {
+			r.openedAt = r.now()
+		} else {
+			r.rollOver()
+		}
+	}
+}
+
+// writeLines writes formatted lines to the live file and counts them, keeping them for the next write when that's
+// enabled and the write fails. Buffered bytes and separators are counted too, since they're always flushed to the
+// file before it's rotated
+func (r *RollingWriter) writeLines(data []byte, lines int) error {
+	count, err := r.writeRetrying(data)
+	r.bytesWritten += int64(count)
+	if err != nil {
+		r.keep(data[count:], lines)
+		return err
+	}
+
+	r.linesWritten += int64(lines)
+	r.metrics.Written(count)
+	r.unsynced += lines
+	if r.syncEvery > 0 && r.unsynced >= r.syncEvery {
+		r.syncPending()
+	}
+	return nil
+}
+
+// wouldFill determines if writing the supplied number of bytes and lines would take the live file to the maximum size
+// or line count
+func (r *RollingWriter) wouldFill(size int64, lines int64) bool {
+	return r.bytesWritten+size >= r.maxSize || (r.maxLines > 0 && r.linesWritten+lines >= r.maxLines)
+}
+
+// Flush flushes any buffered log lines to the file
+func (r *RollingWriter) Flush() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.closed || r.paused() {
+		return
+	}
+
+	err := r.flushBuffer()
+	if err != nil {
+		r.handleError(fmt.Errorf("failed to flush log file: %w", err))
+		r.backOff(err)
+		return
+	}
+
+	// Sync lines written since the last sync, so a quiet log is still synced at the flush interval
+	if r.syncEvery > 0 && r.unsynced > 0 {
+		r.syncPending()
+	}
+}
+
+// syncPending flushes and syncs the live file, bounding how many lines a crash can lose when syncing every N writes
+func (r *RollingWriter) syncPending() {
+	r.unsynced = 0
+	err := r.flushBuffer()
+	if err == nil {
+		err = r.syncFile()
+	}
+
+	if err != nil {
+		r.handleError(fmt.Errorf("failed to sync log file: %w", err))
+	}
+}
+
+// Rotate immediately rotates the live file and deletes old files, returning an error if the writer is closed
+func (r *RollingWriter) Rotate() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.closed {
+		return errClosed
+	}
+
+	err := r.rotate()
+	if err != nil {
+		return err
+	}
+	return r.deleteOld()
+}
+
+// Reopen closes and reopens the live file by name, so lines go to a new file once it's been moved by an external
+// rotation tool
+func (r *RollingWriter) Reopen() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.closed {
+		return errClosed
+	}
+	return r.reopen()
+}
+
+// reopen opens the live file by name again, which creates it if it was moved, and closes the previously open file. The
+// previous file stays live if the new one can't be opened
+func (r *RollingWriter) reopen() error {
+	file, err := r.openPath(r.fileName, 0)
+	if err != nil {
+		return err
+	}
+
+	// Flush and close the previous file, moving on to the new one even if that fails
+	err = r.closeLive()
+	r.useFile(file)
+	if err != nil {
+		return err
+	}
+
+	// Track the size and age of whatever file is there now
+	info, err := r.file.Stat()
+	if err != nil {
+		return err
+	}
+
+	r.bytesWritten = info.Size()
+	r.openedAt = r.now()
+	if info.Size() > 0 {
+		r.openedAt = info.ModTime()
+	}
+	return r.resume()
+}
+
+// Path gets the absolute path of the live file, which is the currently expanded name when the file name has date
+// placeholders
+func (r *RollingWriter) Path() string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.fileName
+}
+
+// CurrentSize gets the number of bytes written to the live file, including any that are still buffered
+func (r *RollingWriter) CurrentSize() int64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.bytesWritten
+}
+
+// RotationCount gets the number of rotations the writer has performed
+func (r *RollingWriter) RotationCount() int64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.rotations
+}
+
+// Sync flushes any buffered data and commits the live file to stable storage
+func (r *RollingWriter) Sync() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.closed {
+		return nil
+	}
+
+	err := r.flushBuffer()
+	if err != nil {
+		return err
+	}
+	return r.syncFile()
+}
+
+// Close flushes, syncs, and closes the writer
+func (r *RollingWriter) Close() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.closed {
+		return nil
+	}
+
+	// Make a last attempt at writing lines kept from failed writes
+	var err error
+	if len(r.kept) > 0 {
+		_, err = r.writeRetrying(r.kept)
+		r.kept, r.keptLines = nil, 0
+	}
+
 	r.closed = true
+	close(r.done)
+	return errors.Join(err, r.closeLive())
 }
@@ -0,0 +1,18 @@
+//go:build !windows
+// +build !windows
+
+package logpher
+
+import (
+	"time"
+)
+
+// archiveLayout defines the timestamp layout for rotated file suffixes. It uses fixed width nanoseconds so that
+// multiple rotations within a second don't collide and the names still sort lexically, as long as they're in UTC
+const archiveLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+// parseArchiveTime parses a rotated file timestamp suffix. Parsing with RFC3339 accepts both the current sub-second
+// suffixes and the older second precision ones
+func parseArchiveTime(suffix string) (time.Time, error) {
+	return time.Parse(time.RFC3339, suffix)
+}
@@ -0,0 +1,65 @@
+//go:build windows
+// +build windows
+
+package logpher
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestArchiveNamesWithoutColons makes sure rotated files get names Windows allows, and are still recognized as archives
+// when old ones are deleted
+func TestArchiveNamesWithoutColons(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "test.log")
+	now := time.Date(2024, time.March, 1, 12, 30, 45, 0, time.UTC)
+	clock := func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	writer, err := NewRollingWriter(fileName, WithMaxSize(1), WithMaxCount(2), WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+
+	// Each line fills the file and rotates it, so only the last two archives should be kept
+	logger := New(nil).NewLogger("test")
+	for _, line := range []string{"first", "second", "third"} {
+		writer.Write(logger, Info, line, nil)
+	}
+
+	archives, err := filepath.Glob(fileName + ".*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archives) != 2 {
+		t.Fatalf("expected 2 archives, got %v", archives)
+	}
+
+	for _, path := range archives {
+		if strings.Contains(filepath.Base(path), ":") {
+			t.Errorf("expected archive name without colons, got %s", path)
+		}
+	}
+}
+
+// TestParseArchiveTime makes sure the colon free suffixes parse back to the time they were written at
+func TestParseArchiveTime(t *testing.T) {
+	expected := time.Date(2024, time.March, 1, 12, 30, 45, 123456789, time.UTC)
+	suffix := expected.Format(archiveLayout)
+	if suffix != "2024-03-01T12-30-45.123456789Z" {
+		t.Fatalf("unexpected suffix %s", suffix)
+	}
+
+	parsed, err := parseArchiveTime(suffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.Equal(expected) {
+		t.Errorf("expected %v, got %v", expected, parsed)
+	}
+}
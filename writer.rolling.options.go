@@ -0,0 +1,230 @@
+package logpher
+
+import "time"
+
+// Option defines a functional option for configuring a rolling writer
+type Option func(*RollingWriter)
+
+// WithSyncWrites sets whether the live file is opened with O_SYNC, so each write only returns once the line is on disk.
+// This survives power loss at a large cost to throughput, and is best combined with buffering or used for audit logs
+func WithSyncWrites(syncWrites bool) Option {
+	return func(r *RollingWriter) {
+		r.syncWrites = syncWrites
+	}
+}
+
+// WithSyncEvery syncs the live file to disk after every N writes, so at most N lines are lost in a crash, disabled
+// when zero. With a flush interval, lines written since the last sync are also synced at each flush
+func WithSyncEvery(writes int) Option {
+	return func(r *RollingWriter) {
+		r.syncEvery = writes
+	}
+}
+
+// WithMaxSize sets the size in bytes the live file is rotated at, which has to be positive
+func WithMaxSize(bytes int64) Option {
+	return func(r *RollingWriter) {
+		r.maxSize = bytes
+	}
+}
+
+// WithMaxLines sets the number of lines the live file is rotated at, in addition to the size, disabled when zero
+func WithMaxLines(lines int64) Option {
+	return func(r *RollingWriter) {
+		r.maxLines = lines
+	}
+}
+
+// WithMaxCount sets the number of rotated files to keep. Zero keeps none, unless age or total size based retention is
+// set, in which case it doesn't limit the count
+func WithMaxCount(count int) Option {
+	return func(r *RollingWriter) {
+		r.maxCount = count
+	}
+}
+
+// WithMaxAge sets the maximum age of rotated files, disabled when zero
+func WithMaxAge(age time.Duration) Option {
+	return func(r *RollingWriter) {
+		r.maxAge = age
+	}
+}
+
+// WithMaxTotal sets the maximum total size in bytes of rotated files, disabled when zero
+func WithMaxTotal(bytes int64) Option {
+	return func(r *RollingWriter) {
+		r.maxTotal = bytes
+	}
+}
+
+// WithCompression sets whether rotated files are gzipped
+func WithCompression(compress bool) Option {
+	return func(r *RollingWriter) {
+		r.compress = compress
+	}
+}
+
+// WithCompressionLevel sets the gzip level rotated files are compressed at, from gzip.BestSpeed to
+// gzip.BestCompression, defaulting to gzip.DefaultCompression
+func WithCompressionLevel(level int) Option {
+	return func(r *RollingWriter) {
+		r.compressLevel = level
+	}
+}
+
+// WithCompressor sets the compression format for rotated files, such as zstd from the zstd subpackage, instead of
+// gzip. Compression still has to be enabled with WithCompression
+func WithCompressor(compressor Compressor) Option {
+	return func(r *RollingWriter) {
+		r.compressor = compressor
+	}
+}
+
+// WithCompressExisting sets whether rotated files that aren't compressed yet are gzipped when the writer is created,
+// which only applies when compression is enabled
+func WithCompressExisting(compress bool) Option {
+	return func(r *RollingWriter) {
+		r.compressOld = compress
+	}
+}
+
+// WithNumbering sets whether rotated files are suffixed with incrementing numbers instead of timestamps
+func WithNumbering(numbered bool) Option {
+	return func(r *RollingWriter) {
+		r.numbered = numbered
+	}
+}
+
+// WithLocalArchiveTime sets whether rotated file timestamp suffixes use local time instead of UTC. Local suffixes
+// change with the timezone and daylight saving, so they only sort by name within one UTC offset
+func WithLocalArchiveTime(local bool) Option {
+	return func(r *RollingWriter) {
+		r.localArchive = local
+	}
+}
+
+// WithLatestLink sets whether a link to the most recently rotated file is maintained next to the live file
+func WithLatestLink(latest bool) Option {
+	return func(r *RollingWriter) {
+		r.latest = latest
+	}
+}
+
+// WithArchiveDir sets the directory rotated files are moved to, relative to the live file's directory
+func WithArchiveDir(dir string) Option {
+	return func(r *RollingWriter) {
+		r.archiveDir = dir
+	}
+}
+
+// WithInterval sets the time based rotation interval, disabled when zero
+func WithInterval(interval time.Duration) Option {
+	return func(r *RollingWriter) {
+		r.interval = interval
+	}
+}
+
+// WithFlushInterval buffers lines and flushes them at the supplied interval, buffering is disabled when zero
+func WithFlushInterval(interval time.Duration) Option {
+	return func(r *RollingWriter) {
+		r.flushEvery = interval
+	}
+}
+
+// WithWatchInterval checks the live file at the supplied interval, reopening it if it was deleted or replaced, e.g. by
+// a rotation tool that doesn't signal the process. Checking is disabled when zero
+func WithWatchInterval(interval time.Duration) Option {
+	return func(r *RollingWriter) {
+		r.watch = interval
+	}
+}
+
+// WithDiskFullBackoff stops writing for the backoff window after a write fails because the disk is full, skipping
+// lines until it has passed. When freeSpace is set, old files are also deleted as soon as the disk fills up
+func WithDiskFullBackoff(backoff time.Duration, freeSpace bool) Option {
+	return func(r *RollingWriter) {
+		r.backoff = backoff
+		r.freeSpace = freeSpace
+	}
+}
+
+// WithWriteRetries retries failed writes up to the supplied number of times, waiting the backoff before the first retry
+// and doubling it for each one after, so brief failures on network filesystems don't lose lines. Retries are disabled
+// when zero
+func WithWriteRetries(retries int, backoff time.Duration) Option {
+	return func(r *RollingWriter) {
+		r.retries = retries
+		r.retryDelay = backoff
+	}
+}
+
+// WithKeepFailed sets whether lines that still fail to write after any retries are kept and written ahead of the next
+// line, up to 1MB of them, instead of being dropped
+func WithKeepFailed(keep bool) Option {
+	return func(r *RollingWriter) {
+		r.keepFailed = keep
+	}
+}
+
+// WithErrorHandler sets the handler for write, flush, and rotation failures, which are written to the diagnostics output when it's nil
+func WithErrorHandler(handler func(error)) Option {
+	return func(r *RollingWriter) {
+		r.errorHandler = handler
+	}
+}
+
+// WithOnRotate sets a hook that's called on a separate goroutine with the path of each rotated file
+func WithOnRotate(hook func(archivePath string)) Option {
+	return func(r *RollingWriter) {
+		r.onRotate = hook
+	}
+}
+
+// WithMetrics sets the metrics hooks for observing writes, rotations, and failures
+func WithMetrics(metrics Metrics) Option {
+	return func(r *RollingWriter) {
+		r.metrics = metricsOrDefault(metrics)
+	}
+}
+
+// WithSeparator sets the separator written after each line, which can be empty
+func WithSeparator(separator string) Option {
+	return func(r *RollingWriter) {
+		r.separator = separator
+	}
+}
+
+// WithHeader sets a header line written at the start of each new live file, such as a CSV header, disabled when empty
+func WithHeader(header string) Option {
+	return func(r *RollingWriter) {
+		r.header = header
+	}
+}
+
+// WithRotationMarker sets whether each live file started by a rotation begins with a marker line naming the rotated
+// file, formatted like the other lines, so the file boundary can be found when piecing logs back together
+func WithRotationMarker(marker bool) Option {
+	return func(r *RollingWriter) {
+		r.marker = marker
+	}
+}
+
+// WithFormatter sets the formatter for lines, keeping the standard format when it's nil. Only the default standard
+// format is built straight into the write buffer, lines from a formatter are copied in from the string it returns
+func WithFormatter(format Formatter) Option {
+	return func(r *RollingWriter) {
+		if format != nil {
+			r.format = format
+			r.standard = false
+		}
+	}
+}
+
+// WithClock sets the function used to get the current time for rotation, so rotation can be tested deterministically
+func WithClock(now func() time.Time) Option {
+	return func(r *RollingWriter) {
+		if now != nil {
+			r.now = now
+		}
+	}
+}
@@ -0,0 +1,31 @@
+//go:build !windows
+// +build !windows
+
+package logpher
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// swapFiles renames the live file to the archive path and moves the new file into its place, returning the new file.
+// Open files can be renamed, so the live file stays open until it's been replaced and is put back if anything fails
+func (r *RollingWriter) swapFiles(next *os.File, archive string) (*os.File, error) {
+	err := moveFile(r.fileName, archive)
+	if err != nil {
+		return nil, discardFile(next, err)
+	}
+
+	err = os.Rename(next.Name(), r.fileName)
+	if err != nil {
+		return nil, discardFile(next, errors.Join(err, moveFile(archive, r.fileName)))
+	}
+
+	// The rotated file is already in place, so failing to close it doesn't fail the rotation
+	err = r.file.Close()
+	if err != nil {
+		r.handleError(fmt.Errorf("failed to close rotated log file: %w", err))
+	}
+	return next, nil
+}
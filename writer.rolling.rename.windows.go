@@ -0,0 +1,32 @@
+//go:build windows
+// +build windows
+
+package logpher
+
+import (
+	"errors"
+	"os"
+)
+
+// swapFiles renames the live file to the archive path and moves the new file into its place, returning the new file.
+// Windows can't rename open files, so both are closed first. The live file is opened again afterwards, putting the
+// previous one back first if anything failed, and the writer is left without a file only if that can't be opened
+func (r *RollingWriter) swapFiles(next *os.File, archive string) (*os.File, error) {
+	name := next.Name()
+	err := errors.Join(next.Close(), r.closeLive())
+	if err == nil {
+		err = moveFile(r.fileName, archive)
+		if err == nil {
+			err = os.Rename(name, r.fileName)
+			if err != nil {
+				err = errors.Join(err, moveFile(archive, r.fileName))
+			}
+		}
+	}
+
+	if err != nil {
+		_ = os.Remove(name)
+		return nil, errors.Join(err, r.openLive())
+	}
+	return r.openPath(r.fileName, 0)
+}
@@ -0,0 +1,93 @@
+package logpher
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRollingWriterRotatesTwiceInOneInstant makes sure back to back rotations at the same time both keep their archives
+func TestRollingWriterRotatesTwiceInOneInstant(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "test.log")
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	writer, err := NewRollingWriter(fileName, WithMaxSize(1), WithClock(func() time.Time { return now }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+
+	// Each line fills the file, so both rotate it at the same instant
+	logger := New(nil).NewLogger("test")
+	writer.Write(logger, Info, "first", nil)
+	writer.Write(logger, Info, "second", nil)
+
+	archives, err := filepath.Glob(fileName + ".*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(archives)
+	if len(archives) != 2 {
+		t.Fatalf("expected 2 archives, got %v", archives)
+	}
+
+	for i, line := range []string{"first", "second"} {
+		data, err := os.ReadFile(archives[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), line) {
+			t.Errorf("expected archive %s to contain %q, got %q", archives[i], line, data)
+		}
+	}
+}
+
+// TestRollingWriterFormatter makes sure lines are written with a caller supplied formatter
+func TestRollingWriterFormatter(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "test.log")
+	format := func(logger *Logger, level *Level, line string, fields []Field) string {
+		return logger.name + "|" + level.display + "|" + line + "|" + fields[0].String()
+	}
+
+	writer, err := NewRollingWriter(fileName, WithFormatter(format))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writer.Write(New(nil).NewLogger("test"), Warn, "custom", []Field{Any("status", 200)})
+	err = writer.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "TEST|WARN|custom|status=200\n"
+	if string(data) != expected {
+		t.Errorf("expected %q, got %q", expected, data)
+	}
+}
+
+// BenchmarkRollingWriterWrite measures writing standard lines to a rolling file, which builds each line straight into a
+// pooled buffer
+func BenchmarkRollingWriterWrite(b *testing.B) {
+	writer, err := NewRollingWriter(filepath.Join(b.TempDir(), "bench.log"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer writer.Close()
+
+	logger := New(nil).NewLogger("bench")
+	fields := []Field{Any("status", 200), Any("path", "/health")}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		writer.Write(logger, Info, "request handled", fields)
+	}
+}
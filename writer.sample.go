@@ -0,0 +1,145 @@
+package logpher
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sampling keys
+const (
+	sampleByMessage = "message"
+	sampleByLogger  = "logger"
+)
+
+// defaultSampleInterval defines how often sample counts are reset when no interval is supplied
+const defaultSampleInterval = time.Second
+
+// SampleKey defines a function that gets the key lines are counted by when sampling
+type SampleKey func(logger *Logger, level *Level, line string) string
+
+// SampleByMessage counts lines by their logger, level, and message
+func SampleByMessage(logger *Logger, level *Level, line string) string {
+	return logger.name + "\x00" + level.display + "\x00" + line
+}
+
+// SampleByLogger counts lines by their logger
+func SampleByLogger(logger *Logger, _ *Level, _ string) string {
+	return logger.name
+}
+
+// SampleWriter creates a writer that passes the first lines with each key to the supplied writer each interval, then
+// only every thereafter'th line. Nothing after the first lines is passed when thereafter is zero. The interval defaults
+// to a second, and lines are keyed by message when no key is supplied
+func SampleWriter(writer Writer, first int, thereafter int, interval time.Duration, key SampleKey) Writer {
+	return newSampleWriter(writer, first, thereafter, interval, key)
+}
+
+// sampleWriter defines a writer that samples repetitive lines, counting them by key and resetting the counts each
+// interval
+type sampleWriter struct {
+	lock       *sync.Mutex
+	closed     bool
+	writer     Writer
+	first      int
+	thereafter int
+	interval   time.Duration
+	key        SampleKey
+	counts     map[string]int
+	reset      time.Time
+}
+
+// newSampleWriter creates a new sample writer
+func newSampleWriter(writer Writer, first int, thereafter int, interval time.Duration, key SampleKey) *sampleWriter {
+	if interval <= 0 {
+		interval = defaultSampleInterval
+	}
+
+	if key == nil {
+		key = SampleByMessage
+	}
+
+	return &sampleWriter{
+		lock:       &sync.Mutex{},
+		writer:     writer,
+		first:      first,
+		thereafter: thereafter,
+		interval:   interval,
+		key:        key,
+		counts:     map[string]int{},
+	}
+}
+
+// sampleKey gets the sampling key function with the supplied name, keying by message for unknown names
+func sampleKey(name string) SampleKey {
+	if strings.ToLower(name) == sampleByLogger {
+		return SampleByLogger
+	}
+	return SampleByMessage
+}
+
+// Write writes a log line to the underlying writer if it's sampled
+func (s *sampleWriter) Write(logger *Logger, level *Level, line string, fields []Field) {
+	if s.sampled(logger, level, line) {
+		s.writer.Write(logger, level, line, fields)
+	}
+}
+
+// sampled counts a line, determining if it should be written
+func (s *sampleWriter) sampled(logger *Logger, level *Level, line string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return false
+	}
+
+	// Start counting again each interval
+	now := time.Now()
+	if !now.Before(s.reset) {
+		s.counts = map[string]int{}
+		s.reset = now.Add(s.interval)
+	}
+
+	key := s.key(logger, level, line)
+	count := s.counts[key] + 1
+	s.counts[key] = count
+
+	if count <= s.first {
+		return true
+	}
+	return s.thereafter > 0 && (count-s.first)%s.thereafter == 0
+}
+
+// Flush flushes the underlying writer
+func (s *sampleWriter) Flush() {
+	flush(s.writer)
+}
+
+// Rotate rotates the underlying writer
+func (s *sampleWriter) Rotate() error {
+	return rotateWriter(s.writer)
+}
+
+// Reopen reopens the underlying writer
+func (s *sampleWriter) Reopen() error {
+	return reopenWriter(s.writer)
+}
+
+// Sync syncs the underlying writer
+func (s *sampleWriter) Sync() error {
+	return syncWriter(s.writer)
+}
+
+// Close closes the underlying writer
+func (s *sampleWriter) Close() error {
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.lock.Unlock()
+
+	return s.writer.Close()
+}
@@ -0,0 +1,125 @@
+package logpher
+
+import (
+	"path/filepath"
+	"sync"
+)
+
+// The rolling writers opened with OpenSharedFile, by absolute path
+var (
+	sharedLock    = &sync.Mutex{}
+	sharedWriters = map[string]*sharedFile{}
+)
+
+// sharedFile defines a rolling writer shared between handles, along with the number of open handles
+type sharedFile struct {
+	path       string
+	writer     *RollingWriter
+	references int
+}
+
+// OpenSharedFile opens a rolling writer for the supplied file that's shared with every other caller opening the same
+// path, returning a handle to it. The file is only closed once every handle has been closed, so loggers can each own
+// a handle. Options only apply when the file isn't already open, later callers share the existing writer as it is
+func OpenSharedFile(path string, opts ...Option) (Writer, error) {
+	absolutePath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	sharedLock.Lock()
+	defer sharedLock.Unlock()
+
+	shared, ok := sharedWriters[absolutePath]
+	if !ok {
+		writer, err := NewRollingWriter(absolutePath, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		shared = &sharedFile{path: absolutePath, writer: writer}
+		sharedWriters[absolutePath] = shared
+	}
+
+	shared.references++
+	return &sharedHandle{lock: &sync.Mutex{}, shared: shared}, nil
+}
+
+// release drops a reference to the shared file, closing it and forgetting it once there are none left
+func (s *sharedFile) release() error {
+	sharedLock.Lock()
+	defer sharedLock.Unlock()
+
+	s.references--
+	if s.references > 0 {
+		return nil
+	}
+
+	delete(sharedWriters, s.path)
+	return s.writer.Close()
+}
+
+// sharedHandle defines a writer that writes to a shared file until it's closed
+type sharedHandle struct {
+	lock   *sync.Mutex
+	closed bool
+	shared *sharedFile
+}
+
+// Write writes a log line to the shared file
+func (h *sharedHandle) Write(logger *Logger, level *Level, line string, fields []Field) {
+	if !h.isClosed() {
+		h.shared.writer.Write(logger, level, line, fields)
+	}
+}
+
+// Flush flushes the shared file
+func (h *sharedHandle) Flush() {
+	if !h.isClosed() {
+		h.shared.writer.Flush()
+	}
+}
+
+// Rotate rotates the shared file
+func (h *sharedHandle) Rotate() error {
+	if h.isClosed() {
+		return errClosed
+	}
+	return h.shared.writer.Rotate()
+}
+
+// Reopen reopens the shared file
+func (h *sharedHandle) Reopen() error {
+	if h.isClosed() {
+		return errClosed
+	}
+	return h.shared.writer.Reopen()
+}
+
+// Sync syncs the shared file
+func (h *sharedHandle) Sync() error {
+	if h.isClosed() {
+		return nil
+	}
+	return h.shared.writer.Sync()
+}
+
+// Close closes the handle, closing the shared file if it was the last open handle. Closing it again does nothing
+func (h *sharedHandle) Close() error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.closed {
+		return nil
+	}
+
+	h.closed = true
+	return h.shared.release()
+}
+
+// isClosed determines if the handle has been closed
+func (h *sharedHandle) isClosed() bool {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.closed
+}
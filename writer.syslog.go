@@ -0,0 +1,182 @@
+package logpher
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Syslog message formats
+const (
+	rfc3164 = "rfc3164"
+	rfc5424 = "rfc5424"
+)
+
+// Syslog facility and severities
+const (
+	syslogUser    = 1
+	syslogCrit    = 2
+	syslogErr     = 3
+	syslogWarning = 4
+	syslogNotice  = 5
+	syslogInfo    = 6
+	syslogDebug   = 7
+)
+
+// syslogSockets defines the local syslog socket paths to try, in order
+var syslogSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// syslogWriter defines a writer that sends log lines to a local or remote syslog daemon
+type syslogWriter struct {
+	lock     *sync.Mutex
+	closed   bool
+	conn     net.Conn
+	stream   bool
+	network  string
+	address  string
+	protocol string
+	tag      string
+	hostname string
+	format   Formatter
+}
+
+// newSyslogWriter creates a new syslog writer. An empty network connects to the local syslog socket, otherwise the
+// network and address are dialed directly
+func newSyslogWriter(network string, address string, protocol string, tag string, format Formatter) (*syslogWriter, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "-"
+	}
+
+	if tag == "" {
+		tag = filepath.Base(os.Args[0])
+	}
+
+	s := &syslogWriter{
+		lock:     &sync.Mutex{},
+		network:  strings.ToLower(network),
+		address:  address,
+		protocol: strings.ToLower(protocol),
+		tag:      tag,
+		hostname: hostname,
+		format:   format,
+	}
+
+	err = s.connect()
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// connect dials the syslog daemon
+func (s *syslogWriter) connect() error {
+	if s.network != "" {
+		conn, err := net.Dial(s.network, s.address)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+		s.stream = strings.HasPrefix(s.network, "tcp")
+		return nil
+	}
+
+	// Try the local sockets, using the supplied address first if there is one
+	paths := syslogSockets
+	if s.address != "" {
+		paths = []string{s.address}
+	}
+
+	for _, path := range paths {
+		for _, network := range []string{"unixgram", "unix"} {
+			conn, err := net.Dial(network, path)
+			if err == nil {
+				s.conn = conn
+				s.stream = network == "unix"
+				return nil
+			}
+		}
+	}
+	return errors.New("unable to connect to a local syslog socket")
+}
+
+// message builds a syslog message for a formatted log line
+func (s *syslogWriter) message(level *Level, line string) string {
+	priority := syslogUser*8 + syslogSeverity(level)
+	now := time.Now()
+
+	var message string
+	if s.protocol == rfc5424 {
+		message = fmt.Sprintf("<%d>1 %s %s %s %d - - %s", priority, now.Format(time.RFC3339Nano), s.hostname, s.tag, os.Getpid(), line)
+	} else {
+		message = fmt.Sprintf("<%d>%s %s %s[%d]: %s", priority, now.Format(time.Stamp), s.hostname, s.tag, os.Getpid(), line)
+	}
+
+	// Stream connections need a delimiter between messages
+	if s.stream {
+		message += "\n"
+	}
+	return message
+}
+
+// syslogSeverity maps a level to a syslog severity by its severity
+func syslogSeverity(level *Level) int {
+	switch {
+	case level.value >= Fatal.value:
+		return syslogCrit
+	case level.value >= Error.value:
+		return syslogErr
+	case level.value >= Warn.value:
+		return syslogWarning
+	case level.value > Info.value:
+		return syslogNotice
+	case level.value == Info.value:
+		return syslogInfo
+	default:
+		return syslogDebug
+	}
+}
+
+// Write sends a log line to the syslog daemon, reconnecting once if the send fails
+func (s *syslogWriter) Write(logger *Logger, level *Level, line string, fields []Field) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return
+	}
+
+	message := s.message(level, s.format(logger, level, line, fields))
+	_, err := s.conn.Write([]byte(message))
+	if err == nil {
+		return
+	}
+
+	// Reconnect and try again, since the daemon may have restarted
+	_ = s.conn.Close()
+	err = s.connect()
+	if err == nil {
+		_, err = s.conn.Write([]byte(message))
+	}
+	if err != nil {
+		diagnose("Failed to write log line:", err)
+	}
+}
+
+// Close closes the syslog connection
+func (s *syslogWriter) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return nil
+	}
+
+	s.closed = true
+	return s.conn.Close()
+}
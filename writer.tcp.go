@@ -0,0 +1,292 @@
+package logpher
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTCPTimeout = 5 * time.Second
+	defaultTCPBuffer  = 1000
+	minTCPBackoff     = 100 * time.Millisecond
+	maxTCPBackoff     = 30 * time.Second
+)
+
+// tcpWriter defines a writer that streams log lines to a collector over TCP, optionally secured with TLS, reconnecting
+// with backoff in the background when the connection drops and buffering a bounded number of lines while disconnected
+type tcpWriter struct {
+	lock         *sync.Mutex
+	closed       bool
+	conn         net.Conn
+	address      string
+	timeout      time.Duration
+	tlsConfig    *tls.Config
+	errorHandler func(error)
+	pending      []string
+	maxPending   int
+	wake         chan struct{}
+	ctx          context.Context
+	cancel       context.CancelFunc
+	done         chan struct{}
+	format       Formatter
+}
+
+// newTCPWriter creates a new TCP writer, using TLS when a TLS configuration is supplied. It connects in the background,
+// so a collector that isn't reachable yet isn't fatal, lines are buffered until it is
+func newTCPWriter(address string, timeout time.Duration, maxPending int, tlsConfig *tls.Config, errorHandler func(error), format Formatter) *tcpWriter {
+	if timeout <= 0 {
+		timeout = defaultTCPTimeout
+	}
+
+	if maxPending <= 0 {
+		maxPending = defaultTCPBuffer
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &tcpWriter{
+		lock:         &sync.Mutex{},
+		address:      address,
+		timeout:      timeout,
+		tlsConfig:    tlsConfig,
+		errorHandler: errorHandler,
+		maxPending:   maxPending,
+		wake:         make(chan struct{}, 1),
+		ctx:          ctx,
+		cancel:       cancel,
+		done:         make(chan struct{}),
+		format:       format,
+	}
+
+	go t.reconnect()
+	t.wakeUp()
+	return t
+}
+
+// reconnect connects to the collector in the background whenever it's woken up by a dropped connection, so lines are
+// never held up by a dial or handshake
+func (t *tcpWriter) reconnect() {
+	defer close(t.done)
+
+	for {
+		select {
+		case <-t.ctx.Done():
+			return
+		case <-t.wake:
+			t.connect()
+		}
+	}
+}
+
+// wakeUp wakes up the background reconnect, without waiting if it's already been woken
+func (t *tcpWriter) wakeUp() {
+	select {
+	case t.wake <- struct{}{}:
+	default:
+	}
+}
+
+// connect dials the collector until there's a live connection or the writer is closed, backing off exponentially
+// between attempts
+func (t *tcpWriter) connect() {
+	backoff := time.Duration(0)
+	for !t.connected() {
+		if backoff > 0 {
+			select {
+			case <-t.ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+		}
+		backoff = nextTCPBackoff(backoff)
+
+		conn, err := t.dial(t.ctx)
+		if err == nil {
+			t.use(conn)
+		}
+	}
+}
+
+// nextTCPBackoff doubles the reconnect backoff, keeping it between the minimum and maximum
+func nextTCPBackoff(backoff time.Duration) time.Duration {
+	backoff *= 2
+	if backoff < minTCPBackoff {
+		backoff = minTCPBackoff
+	}
+	if backoff > maxTCPBackoff {
+		backoff = maxTCPBackoff
+	}
+	return backoff
+}
+
+// connected determines if there's a live connection, or the writer is closed and there's no point connecting
+func (t *tcpWriter) connected() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.conn != nil || t.closed
+}
+
+// use starts writing to a new connection, sending the lines buffered while disconnected
+func (t *tcpWriter) use(conn net.Conn) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.closed {
+		_ = conn.Close()
+		return
+	}
+
+	t.conn = conn
+	t.send()
+}
+
+// dial connects to the collector within the timeout, establishing a new TLS session on every connection when TLS is
+// configured. Handshake failures are usually configuration problems, such as an untrusted certificate, so they're
+// reported instead of only being retried
+func (t *tcpWriter) dial(ctx context.Context) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: t.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", t.address)
+	if err != nil || t.tlsConfig == nil {
+		return conn, err
+	}
+
+	conn, err = t.handshake(ctx, conn)
+	if err != nil {
+		t.handleError(fmt.Errorf("failed TLS handshake with %s: %w", t.address, err))
+		return nil, err
+	}
+	return conn, nil
+}
+
+// handshake performs the TLS handshake over a new connection within the timeout, closing the connection if it fails
+func (t *tcpWriter) handshake(ctx context.Context, conn net.Conn) (net.Conn, error) {
+
+	// Default the server name to the address host, as tls.Dial does
+	config := t.tlsConfig
+	if config.ServerName == "" {
+		host, _, err := net.SplitHostPort(t.address)
+		if err == nil {
+			config = config.Clone()
+			config.ServerName = host
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	client := tls.Client(conn, config)
+	err := client.HandshakeContext(ctx)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+// handleError reports a failure using the error handler, printing it when there isn't one
+func (t *tcpWriter) handleError(err error) {
+	if t.errorHandler != nil {
+		t.errorHandler(err)
+		return
+	}
+	defaultErrorHandler(err)
+}
+
+// disconnected drops the current connection and wakes up the background reconnect
+func (t *tcpWriter) disconnected() {
+	if t.conn != nil {
+		_ = t.conn.Close()
+		t.conn = nil
+	}
+	t.wakeUp()
+}
+
+// buffer holds a line until the connection is re-established, discarding the oldest line when the buffer is full
+func (t *tcpWriter) buffer(line string) {
+	if len(t.pending) >= t.maxPending {
+		t.pending = t.pending[1:]
+	}
+	t.pending = append(t.pending, line)
+}
+
+// send writes the buffered lines to the connection, keeping them buffered while disconnected or if they couldn't be
+// written. Each write has to finish within the timeout, so a collector that stops reading is treated as a dropped
+// connection instead of blocking every logger sharing the writer
+func (t *tcpWriter) send() {
+	for len(t.pending) > 0 && t.conn != nil {
+		err := t.conn.SetWriteDeadline(time.Now().Add(t.timeout))
+		if err == nil {
+			_, err = t.conn.Write([]byte(t.pending[0]))
+		}
+		if err != nil {
+			t.handleError(fmt.Errorf("failed to write log line: %w", err))
+			t.disconnected()
+			return
+		}
+		t.pending = t.pending[1:]
+	}
+}
+
+// Write streams a log line to the collector, only buffering it while the connection is down
+func (t *tcpWriter) Write(logger *Logger, level *Level, line string, fields []Field) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.closed {
+		return
+	}
+
+	t.buffer(t.format(logger, level, line, fields) + "\n")
+	t.send()
+}
+
+// Flush sends any lines buffered while disconnected, if the connection is back
+func (t *tcpWriter) Flush() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if !t.closed {
+		t.send()
+	}
+}
+
+// Close stops reconnecting in the background, then sends any buffered lines it can and closes the connection, returning
+// an error if lines were left unsent
+func (t *tcpWriter) Close() error {
+	t.lock.Lock()
+	if t.closed {
+		t.lock.Unlock()
+		return nil
+	}
+	t.closed = true
+	t.lock.Unlock()
+
+	t.cancel()
+	<-t.done
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	// Make one last attempt to connect, so lines buffered just before closing aren't lost
+	if t.conn == nil && len(t.pending) > 0 {
+		conn, err := t.dial(context.Background())
+		if err == nil {
+			t.conn = conn
+		}
+	}
+	t.send()
+
+	var err error
+	if len(t.pending) > 0 {
+		err = fmt.Errorf("failed to send %d buffered log lines", len(t.pending))
+	}
+
+	if t.conn != nil {
+		return errors.Join(err, t.conn.Close())
+	}
+	return err
+}
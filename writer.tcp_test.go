@@ -0,0 +1,52 @@
+package logpher
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestTCPWriterCollectorNotReading makes sure a collector that accepts connections but never reads can't block writes
+// once the socket buffers fill up
+func TestTCPWriterCollectorNotReading(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	// Accept connections and hold them open without reading
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	writer := newTCPWriter(listener.Addr().String(), 100*time.Millisecond, 10, nil, func(error) {}, formatStandard)
+	logger := New(nil).NewLogger("test")
+	for !writer.connected() {
+		time.Sleep(10 * time.Millisecond)
+	}
+	line := strings.Repeat("x", 64*kibibyte)
+
+	// Write far more than the socket buffers hold, which blocks forever without a write deadline
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 500; i++ {
+			writer.Write(logger, Info, line, nil)
+		}
+		_ = writer.Close()
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(20 * time.Second):
+		t.Fatal("writes blocked on a collector that isn't reading")
+	}
+}
@@ -0,0 +1,69 @@
+package logpher
+
+// LevelWriter creates a writer that only passes lines at or above the minimum level to the supplied writer
+func LevelWriter(writer Writer, minimum *Level) Writer {
+	return newThresholdWriter(writer, minimum)
+}
+
+// LevelRangeWriter creates a writer that only passes lines between the minimum and maximum levels, inclusive, to the
+// supplied writer. Passing the same level for both accepts that level only
+func LevelRangeWriter(writer Writer, minimum *Level, maximum *Level) Writer {
+	threshold := newThresholdWriter(writer, minimum)
+	threshold.maximum = maximum
+	return threshold
+}
+
+// ErrorSplitWriter creates a writer that writes every line to the first writer, and also writes errors and above to
+// the second one, e.g. to have an error log next to the full application log
+func ErrorSplitWriter(all Writer, errors Writer) Writer {
+	return MultiWriter(all, LevelWriter(errors, Error))
+}
+
+// thresholdWriter defines a writer that only passes lines at or above a minimum level, and optionally at or below a
+// maximum level, to an underlying writer
+type thresholdWriter struct {
+	writer  Writer
+	minimum *Level
+	maximum *Level
+}
+
+// newThresholdWriter creates a new threshold writer without a maximum level
+func newThresholdWriter(writer Writer, minimum *Level) *thresholdWriter {
+	return &thresholdWriter{
+		writer:  writer,
+		minimum: minimum,
+	}
+}
+
+// Write writes a log line to the underlying writer if its level is within the range
+func (t *thresholdWriter) Write(logger *Logger, level *Level, line string, fields []Field) {
+	if level.value < t.minimum.value || (t.maximum != nil && level.value > t.maximum.value) {
+		return
+	}
+	t.writer.Write(logger, level, line, fields)
+}
+
+// Flush flushes the underlying writer
+func (t *thresholdWriter) Flush() {
+	flush(t.writer)
+}
+
+// Rotate rotates the underlying writer
+func (t *thresholdWriter) Rotate() error {
+	return rotateWriter(t.writer)
+}
+
+// Reopen reopens the underlying writer
+func (t *thresholdWriter) Reopen() error {
+	return reopenWriter(t.writer)
+}
+
+// Sync syncs the underlying writer
+func (t *thresholdWriter) Sync() error {
+	return syncWriter(t.writer)
+}
+
+// Close closes the underlying writer
+func (t *thresholdWriter) Close() error {
+	return t.writer.Close()
+}
@@ -0,0 +1,86 @@
+package logpher
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"unicode/utf8"
+)
+
+// defaultUDPSize defines the default maximum datagram size, the largest UDP payload over IPv4
+const defaultUDPSize = 65507
+
+// udpWriter defines a fire-and-forget writer that sends each log line as a single datagram
+type udpWriter struct {
+	lock    *sync.Mutex
+	closed  bool
+	conn    net.Conn
+	maxSize int
+	errors  io.Writer
+	format  Formatter
+}
+
+// newUDPWriter creates a new UDP writer. Dropped lines are reported to the supplied error writer when it's not nil
+func newUDPWriter(address string, maxSize int, errors io.Writer, format Formatter) (*udpWriter, error) {
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	if maxSize <= 0 {
+		maxSize = defaultUDPSize
+	}
+
+	return &udpWriter{
+		lock:    &sync.Mutex{},
+		conn:    conn,
+		maxSize: maxSize,
+		errors:  errors,
+		format:  format,
+	}, nil
+}
+
+// Write sends a log line as a datagram, truncating it to the maximum size and dropping it if the send fails
+func (u *udpWriter) Write(logger *Logger, level *Level, line string, fields []Field) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	if u.closed {
+		return
+	}
+
+	_, err := u.conn.Write([]byte(truncate(u.format(logger, level, line, fields), u.maxSize)))
+	if err != nil && u.errors != nil {
+		_, _ = fmt.Fprintln(u.errors, "Dropped log line:", err)
+	}
+}
+
+// Close closes the writer
+func (u *udpWriter) Close() error {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	if u.closed {
+		return nil
+	}
+
+	u.closed = true
+	return u.conn.Close()
+}
+
+// truncate shortens a string to at most the supplied number of bytes without splitting a multi-byte character
+func truncate(value string, size int) string {
+	if len(value) <= size {
+		return value
+	}
+
+	if size <= 0 {
+		return ""
+	}
+
+	for size > 0 && !utf8.RuneStart(value[size]) {
+		size--
+	}
+	return value[:size]
+}
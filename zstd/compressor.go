@@ -0,0 +1,40 @@
+package zstd
+
+import (
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/miratronix/logpher"
+)
+
+// extension defines the extension of zstd compressed files
+const extension = ".zst"
+
+// Compressor defines a logpher compression format that writes rotated files as zstd
+type Compressor struct {
+	level zstd.EncoderLevel
+}
+
+// NewCompressor creates a zstd compressor at the default level. Set it as the logpher configuration's Compressor, or
+// pass it to logpher.WithCompressor, along with enabling compression
+func NewCompressor() *Compressor {
+	return NewCompressorLevel(zstd.SpeedDefault)
+}
+
+// NewCompressorLevel creates a zstd compressor at the supplied level
+func NewCompressorLevel(level zstd.EncoderLevel) *Compressor {
+	return &Compressor{level: level}
+}
+
+// Extension gets the zstd extension
+func (c *Compressor) Extension() string {
+	return extension
+}
+
+// NewWriter creates a zstd writer at the compressor's level
+func (c *Compressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return zstd.NewWriter(w, zstd.WithEncoderLevel(c.level))
+}
+
+// Make sure the compressor can be used as a logpher compression format
+var _ logpher.Compressor = &Compressor{}
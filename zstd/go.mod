@@ -0,0 +1,17 @@
+module github.com/miratronix/logpher/zstd
+
+go 1.21
+
+require (
+	github.com/klauspost/compress v1.17.7
+	github.com/miratronix/logpher v0.0.0
+)
+
+require (
+	github.com/fatih/color v1.7.0 // indirect
+	github.com/mattn/go-colorable v0.1.1 // indirect
+	github.com/mattn/go-isatty v0.0.7 // indirect
+	golang.org/x/sys v0.0.0-20190222072716-a9d3bda3a223 // indirect
+)
+
+replace github.com/miratronix/logpher => ../